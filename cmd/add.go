@@ -41,10 +41,14 @@ func addPath(repo *git.Repository, path string) error {
 		return fmt.Errorf("路径错误: %v", err)
 	}
 
-	// 检查路径是否存在
+	// 检查路径是否存在，已删除的跟踪文件会暂存删除
 	info, err := os.Stat(absPath)
 	if os.IsNotExist(err) {
-		return fmt.Errorf("路径不存在")
+		if err := repo.AddToStaging(absPath); err != nil {
+			return err
+		}
+		fmt.Printf("已暂存删除 %s\n", path)
+		return nil
 	}
 	if err != nil {
 		return fmt.Errorf("访问路径失败: %v", err)
@@ -68,16 +72,16 @@ func addFile(repo *git.Repository, absPath, originalPath string) error {
 	return nil
 }
 
-// addDirectory 递归添加目录中的所有文件
+// addDirectory 递归添加目录中的所有文件，并暂存目录下已删除的跟踪文件
 func addDirectory(repo *git.Repository, absPath, originalPath string) error {
-	return filepath.Walk(absPath, func(filePath string, info os.FileInfo, err error) error {
+	err := filepath.Walk(absPath, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// 跳过目录本身和.cit目录
+		// 跳过目录本身和仓库元数据目录
 		if info.IsDir() {
-			if strings.HasSuffix(filePath, ".cit-version01-无法批量提交") || strings.Contains(filePath, ".cit-version01-无法批量提交"+string(filepath.Separator)) {
+			if info.Name() == git.CitDirName || info.Name() == git.GitDirName {
 				return filepath.SkipDir
 			}
 			return nil
@@ -102,4 +106,25 @@ func addDirectory(repo *git.Repository, absPath, originalPath string) error {
 
 		return repo.AddToStaging(filePath)
 	})
+	if err != nil {
+		return err
+	}
+
+	status, err := repo.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	for _, deleted := range status.DeletedFiles {
+		deletedPath := filepath.Join(repo.Path, filepath.FromSlash(deleted))
+		if deletedPath != absPath && !strings.HasPrefix(deletedPath, absPath+string(filepath.Separator)) {
+			continue
+		}
+		if err := repo.AddToStaging(deletedPath); err != nil {
+			return err
+		}
+		fmt.Printf("已暂存删除 %s\n", deleted)
+	}
+
+	return nil
 }
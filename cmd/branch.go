@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"cit/internal/git"
 
@@ -9,10 +10,15 @@ import (
 )
 
 var branchCmd = &cobra.Command{
-	Use:   "branch [分支名]",
+	Use:   "branch [分支名] [起点]",
 	Short: "管理分支",
-	Long:  "创建、列出或删除分支",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `创建、列出、重命名或删除分支：
+  cit branch                    列出分支（-v 显示最新提交，-vv 同时显示上游分支）
+  cit branch <分支名> [起点]      创建分支
+  cit branch -d <分支名>          删除已合并的分支（-D 强制删除）
+  cit branch -m [旧名称] <新名称>  重命名分支
+  cit branch -u <上游> [分支名]    设置跟踪的上游分支`,
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 查找Git仓库
 		repo, err := git.FindRepository(".")
@@ -20,27 +26,74 @@ var branchCmd = &cobra.Command{
 			return fmt.Errorf("未找到Git仓库: %v", err)
 		}
 
-		if len(args) == 0 {
-			// 列出所有分支
-			branches, err := repo.ListBranches()
-			if err != nil {
-				return fmt.Errorf("获取分支列表失败: %v", err)
+		del, _ := cmd.Flags().GetBool("delete")
+		forceDelete, _ := cmd.Flags().GetBool("force-delete")
+		if del || forceDelete {
+			if len(args) == 0 {
+				return fmt.Errorf("请指定要删除的分支")
 			}
-
-			fmt.Println("分支列表:")
-			for _, branch := range branches {
-				if branch.Name == repo.GetCurrentBranch() {
-					fmt.Printf("* %s\n", branch.Name)
-				} else {
-					fmt.Printf("  %s\n", branch.Name)
+			for _, name := range args {
+				head, err := repo.DeleteBranch(name, forceDelete)
+				if err != nil {
+					return fmt.Errorf("删除分支失败: %v", err)
 				}
+				fmt.Printf("已删除分支 %s（曾指向 %s）\n", name, shortHash(head))
 			}
 			return nil
 		}
 
+		if rename, _ := cmd.Flags().GetBool("move"); rename {
+			if len(args) == 0 {
+				return fmt.Errorf("请指定新的分支名")
+			}
+			oldName, newName := "", args[0]
+			if len(args) > 1 {
+				oldName, newName = args[0], args[1]
+			}
+			if err := repo.RenameBranch(oldName, newName); err != nil {
+				return fmt.Errorf("重命名分支失败: %v", err)
+			}
+			fmt.Printf("已将分支重命名为: %s\n", newName)
+			return nil
+		}
+
+		if upstream, _ := cmd.Flags().GetString("set-upstream-to"); upstream != "" {
+			var branchName string
+			if len(args) > 0 {
+				branchName = args[0]
+			}
+			if err := repo.SetUpstream(branchName, upstream); err != nil {
+				return fmt.Errorf("设置上游分支失败: %v", err)
+			}
+			fmt.Printf("已设置上游分支: %s\n", upstream)
+			return nil
+		}
+
+		if unset, _ := cmd.Flags().GetBool("unset-upstream"); unset {
+			var branchName string
+			if len(args) > 0 {
+				branchName = args[0]
+			}
+			if err := repo.SetUpstream(branchName, ""); err != nil {
+				return fmt.Errorf("取消上游分支失败: %v", err)
+			}
+			fmt.Println("已取消上游分支")
+			return nil
+		}
+
+		if len(args) == 0 {
+			// 列出所有分支
+			verbose, _ := cmd.Flags().GetCount("verbose")
+			return listBranches(repo, verbose)
+		}
+
 		// 创建新分支
 		branchName := args[0]
-		if err := repo.CreateBranch(branchName); err != nil {
+		var startPoint string
+		if len(args) > 1 {
+			startPoint = args[1]
+		}
+		if err := repo.CreateBranchAt(branchName, startPoint); err != nil {
 			return fmt.Errorf("创建分支失败: %v", err)
 		}
 
@@ -48,3 +101,78 @@ var branchCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// listBranches 列出分支，verbose 为1时显示最新提交，为2时再显示上游分支和领先/落后的提交数
+func listBranches(repo *git.Repository, verbose int) error {
+	branches, err := repo.BranchDetails()
+	if err != nil {
+		return fmt.Errorf("获取分支列表失败: %v", err)
+	}
+
+	width := 0
+	for _, branch := range branches {
+		if len(branch.Name) > width {
+			width = len(branch.Name)
+		}
+	}
+
+	fmt.Println("分支列表:")
+	if repo.IsDetached() {
+		name := fmt.Sprintf("(HEAD 分离于 %s)", shortHash(repo.DetachedHead))
+		if verbose == 0 {
+			fmt.Printf("* %s\n", name)
+		} else if commit, err := repo.Storage.GetCommit(repo.DetachedHead); err == nil {
+			fmt.Printf("* %s %s %s\n", name, shortHash(commit.ID), firstLine(commit.Message))
+		}
+	}
+
+	for _, branch := range branches {
+		marker := " "
+		if branch.Current {
+			marker = "*"
+		}
+		if verbose == 0 {
+			fmt.Printf("%s %s\n", marker, branch.Name)
+			continue
+		}
+
+		var tracking string
+		if verbose > 1 && branch.Upstream != "" {
+			tracking = "[" + branch.Upstream + trackingSummary(branch) + "] "
+		}
+		head := "(尚无提交)"
+		if branch.Head != "" {
+			head = shortHash(branch.Head)
+		}
+		fmt.Printf("%s %-*s %s %s%s\n", marker, width, branch.Name, head, tracking, branch.Subject)
+	}
+	return nil
+}
+
+// trackingSummary 描述分支与上游的差距，例如 ": 领先 2，落后 1"
+func trackingSummary(branch *git.BranchInfo) string {
+	if branch.Gone {
+		return ": 已不存在"
+	}
+
+	var parts []string
+	if branch.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("领先 %d", branch.Ahead))
+	}
+	if branch.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("落后 %d", branch.Behind))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(parts, "，")
+}
+
+func init() {
+	branchCmd.Flags().BoolP("delete", "d", false, "删除已合并的分支")
+	branchCmd.Flags().BoolP("force-delete", "D", false, "强制删除分支，即使尚未合并")
+	branchCmd.Flags().BoolP("move", "m", false, "重命名分支")
+	branchCmd.Flags().CountP("verbose", "v", "显示分支头提交，重复两次时显示上游分支")
+	branchCmd.Flags().StringP("set-upstream-to", "u", "", "设置跟踪的上游分支")
+	branchCmd.Flags().Bool("unset-upstream", false, "取消跟踪上游分支")
+}
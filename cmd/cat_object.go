@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cit/internal/git"
+	"cit/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var catObjectCmd = &cobra.Command{
+	Use:   "cat-object (-t | -p) <哈希>",
+	Short: "显示对象的类型或内容",
+	Long:  "底层命令：直接读取对象库中的对象，-t 显示对象类型，-p 输出对象内容",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		showType, _ := cmd.Flags().GetBool("type")
+		pretty, _ := cmd.Flags().GetBool("pretty")
+		if showType == pretty {
+			return fmt.Errorf("必须且只能指定 -t 或 -p 之一")
+		}
+
+		hash := args[0]
+		if showType {
+			objectType, err := repo.Storage.ObjectType(hash)
+			if err != nil {
+				return err
+			}
+			fmt.Println(objectType)
+			return nil
+		}
+
+		objectType, content, err := repo.Storage.ReadObject(hash)
+		if err != nil {
+			return fmt.Errorf("读取对象 %s 失败: %v", hash, err)
+		}
+
+		// 树对象在Git格式中是二进制的，统一按文本格式输出
+		if objectType == storage.TypeTree {
+			tree, err := repo.Storage.GetTree(hash)
+			if err != nil {
+				return err
+			}
+			for _, entry := range tree.Entries {
+				fmt.Printf("%s %s %s\t%s\n", entry.Mode, entry.Type, entry.Hash, entry.Name)
+			}
+			return nil
+		}
+
+		_, err = os.Stdout.Write(content)
+		return err
+	},
+}
+
+func init() {
+	catObjectCmd.Flags().BoolP("type", "t", false, "显示对象类型")
+	catObjectCmd.Flags().BoolP("pretty", "p", false, "输出对象内容")
+}
@@ -2,17 +2,21 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"cit/internal/git"
+	"cit/internal/storage"
 
 	"github.com/spf13/cobra"
 )
 
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout <分支名>",
-	Short: "切换到指定分支",
-	Long:  "切换到指定的分支，更新工作目录",
-	Args:  cobra.ExactArgs(1),
+	Use:   "checkout <分支名 | 修订> | -b <新分支> [起点]",
+	Short: "切换到指定分支或提交",
+	Long: `切换到指定的分支，更新工作目录。
+参数不是分支名时切换到该修订对应的提交，HEAD 进入分离状态；
+使用 -b 时先在起点（默认当前提交）创建新分支再切换`,
+	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		branchName := args[0]
 
@@ -22,6 +26,19 @@ var checkoutCmd = &cobra.Command{
 			return fmt.Errorf("未找到Git仓库: %v", err)
 		}
 
+		newBranch, _ := cmd.Flags().GetBool("branch")
+		if newBranch {
+			var startPoint string
+			if len(args) > 1 {
+				startPoint = args[1]
+			}
+			if err := repo.CreateBranchAt(branchName, startPoint); err != nil {
+				return fmt.Errorf("创建分支失败: %v", err)
+			}
+		} else if len(args) > 1 {
+			return fmt.Errorf("只有使用 -b 时才能指定起点")
+		}
+
 		// 检查分支是否存在
 		branches, err := repo.ListBranches()
 		if err != nil {
@@ -36,16 +53,67 @@ var checkoutCmd = &cobra.Command{
 			}
 		}
 
+		// 离开分离的 HEAD 前找出切换后不再可达的提交
+		var target string
 		if !branchExists {
-			return fmt.Errorf("分支 '%s' 不存在", branchName)
+			if target, err = repo.ResolveRevision(branchName); err != nil {
+				return fmt.Errorf("切换失败: %v", err)
+			}
+		}
+		orphans, err := repo.OrphanedCommits(target)
+		if err != nil {
+			return fmt.Errorf("检查分离提交失败: %v", err)
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if branchExists {
+			// 切换到指定分支
+			if err := repo.CheckoutBranch(branchName, force); err != nil {
+				return fmt.Errorf("切换分支失败: %v", err)
+			}
+			warnOrphanedCommits(orphans)
+			fmt.Printf("已切换到分支: %s\n", branchName)
+			return nil
 		}
 
-		// 切换到指定分支
-		if err := repo.CheckoutBranch(branchName); err != nil {
-			return fmt.Errorf("切换分支失败: %v", err)
+		// 切换到提交，进入分离状态
+		commitID, err := repo.CheckoutCommit(target, force)
+		if err != nil {
+			return fmt.Errorf("切换失败: %v", err)
 		}
+		warnOrphanedCommits(orphans)
 
-		fmt.Printf("已切换到分支: %s\n", branchName)
+		commit, err := repo.Storage.GetCommit(commitID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("HEAD 现在位于 %s %s\n", shortHash(commitID), firstLine(commit.Message))
+		fmt.Println("你正处于分离 HEAD 状态，可以查看、试验并提交修改；")
+		fmt.Println("如需保留在此状态下创建的提交，请使用 cit checkout -b <新分支> 创建分支")
 		return nil
 	},
 }
+
+// warnOrphanedCommits 提示离开分离 HEAD 时留下的提交
+func warnOrphanedCommits(orphans []*storage.Commit) {
+	if len(orphans) == 0 {
+		return
+	}
+
+	fmt.Printf("警告: 你正在离开 %d 个不属于任何分支的提交:\n", len(orphans))
+	for _, commit := range orphans {
+		fmt.Printf("  %s %s\n", shortHash(commit.ID), firstLine(commit.Message))
+	}
+	fmt.Printf("如需保留它们，请使用 cit branch <新分支> %s 创建分支\n", orphans[0].ID)
+}
+
+// firstLine 返回提交信息的第一行
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
+
+func init() {
+	checkoutCmd.Flags().BoolP("force", "f", false, "强制切换，丢弃本地修改")
+	checkoutCmd.Flags().BoolP("branch", "b", false, "创建新分支并切换")
+}
@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
-	"strings"
 
 	"cit/internal/git"
 
@@ -16,17 +14,26 @@ var commitCmd = &cobra.Command{
 	Short: "提交暂存区的更改",
 	Long:  "将暂存区的更改提交到仓库，创建一个新的提交记录",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		message, _ := cmd.Flags().GetString("message")
-		if message == "" {
-			return fmt.Errorf("必须提供提交信息，使用 -m 标志")
-		}
-
 		// 查找Git仓库
 		repo, err := git.FindRepository(".")
 		if err != nil {
 			return fmt.Errorf("未找到Git仓库: %v", err)
 		}
 
+		// 结束合并时可以使用默认的合并提交信息
+		mergeState, err := repo.GetMergeState()
+		if err != nil {
+			return err
+		}
+
+		message, _ := cmd.Flags().GetString("message")
+		if message == "" && mergeState != nil {
+			message = mergeState.Message
+		}
+		if message == "" {
+			return fmt.Errorf("必须提供提交信息，使用 -m 标志")
+		}
+
 		// 检查是否使用了 -a 标志
 		addAll, _ := cmd.Flags().GetBool("all")
 		if addAll {
@@ -36,8 +43,8 @@ var commitCmd = &cobra.Command{
 			}
 		}
 
-		// 检查暂存区是否有内容
-		if repo.IsStagingEmpty() {
+		// 检查暂存区是否有内容，结束合并时允许暂存区为空
+		if repo.IsStagingEmpty() && mergeState == nil {
 			return fmt.Errorf("暂存区为空，没有可提交的更改")
 		}
 
@@ -60,37 +67,21 @@ var commitCmd = &cobra.Command{
 func init() {
 	commitCmd.Flags().StringP("message", "m", "", "提交信息")
 	commitCmd.Flags().BoolP("all", "a", false, "自动添加所有已跟踪的修改文件")
-	commitCmd.MarkFlagRequired("message")
 }
 
-// autoAddModifiedFiles 自动添加所有已跟踪的修改文件
+// autoAddModifiedFiles 自动添加所有已跟踪的修改文件，包括已删除的文件
 func autoAddModifiedFiles(repo *git.Repository) error {
-	// 简化实现：添加当前目录下所有非隐藏文件
-	// 在实际的Git中，这会只添加已跟踪的修改文件
-	return filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// 跳过目录和隐藏文件/目录
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// 跳过隐藏文件
-		if strings.HasPrefix(info.Name(), ".") {
-			return nil
-		}
-
-		// 添加文件到暂存区
-		absPath, err := filepath.Abs(path)
-		if err != nil {
+	status, err := repo.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	files := append(status.ModifiedFiles, status.DeletedFiles...)
+	for _, file := range files {
+		if err := repo.AddToStaging(filepath.Join(repo.Path, filepath.FromSlash(file))); err != nil {
 			return err
 		}
+	}
 
-		return repo.AddToStaging(absPath)
-	})
+	return nil
 }
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"cit/internal/diff"
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [修订] [修订]",
+	Short: "显示文件差异",
+	Long: `以统一差异格式显示快照之间的变化：
+  cit diff               暂存区与工作目录之间的差异
+  cit diff --staged      HEAD与暂存区之间的差异
+  cit diff <修订>         指定修订与工作目录之间的差异
+  cit diff <修订> <修订>   两个修订之间的差异`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		opts := git.DiffOptions{Revisions: args}
+		staged, _ := cmd.Flags().GetBool("staged")
+		cached, _ := cmd.Flags().GetBool("cached")
+		opts.Staged = staged || cached
+
+		changes, err := repo.Diff(opts)
+		if err != nil {
+			return fmt.Errorf("比较差异失败: %v", err)
+		}
+
+		if nameOnly, _ := cmd.Flags().GetBool("name-only"); nameOnly {
+			for _, change := range changes {
+				fmt.Println(change.Path)
+			}
+			return nil
+		}
+
+		if stat, _ := cmd.Flags().GetBool("stat"); stat {
+			printDiffStat(changes)
+			return nil
+		}
+
+		context, _ := cmd.Flags().GetInt("unified")
+		for _, change := range changes {
+			if err := printFileDiff(change, context); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// printFileDiff 以统一差异格式输出单个文件的变化
+func printFileDiff(change *git.FileChange, context int) error {
+	oldName, newName := "a/"+change.Path, "b/"+change.Path
+	fmt.Printf("diff --git %s %s\n", oldName, newName)
+
+	switch change.Type {
+	case git.ChangeAdded:
+		fmt.Printf("new file mode %s\n", change.NewMode)
+		oldName = ""
+	case git.ChangeDeleted:
+		fmt.Printf("deleted file mode %s\n", change.OldMode)
+		newName = ""
+	default:
+		if change.OldMode != change.NewMode {
+			fmt.Printf("old mode %s\nnew mode %s\n", change.OldMode, change.NewMode)
+		}
+	}
+
+	if change.OldHash == change.NewHash {
+		// 只有模式变化
+		return nil
+	}
+	fmt.Printf("index %s..%s\n", shortHash(change.OldHash), shortHash(change.NewHash))
+
+	if diff.IsBinary(change.OldContent) || diff.IsBinary(change.NewContent) {
+		fmt.Printf("Binary files %s and %s differ\n", orDevNull(oldName), orDevNull(newName))
+		return nil
+	}
+	return diff.WriteUnified(os.Stdout, oldName, newName, change.OldContent, change.NewContent, context)
+}
+
+// printDiffStat 输出每个文件增删行数的统计
+func printDiffStat(changes []*git.FileChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	const maxBar = 50
+	type stat struct {
+		added, deleted int
+		binary         bool
+	}
+
+	stats := make([]stat, len(changes))
+	nameWidth, maxTotal := 0, 0
+	totalAdded, totalDeleted := 0, 0
+	for i, change := range changes {
+		if len(change.Path) > nameWidth {
+			nameWidth = len(change.Path)
+		}
+		if diff.IsBinary(change.OldContent) || diff.IsBinary(change.NewContent) {
+			stats[i].binary = true
+			continue
+		}
+		added, deleted := diff.Count(change.OldContent, change.NewContent)
+		stats[i] = stat{added: added, deleted: deleted}
+		totalAdded += added
+		totalDeleted += deleted
+		if added+deleted > maxTotal {
+			maxTotal = added + deleted
+		}
+	}
+
+	for i, change := range changes {
+		s := stats[i]
+		if s.binary {
+			fmt.Printf(" %-*s | Bin\n", nameWidth, change.Path)
+			continue
+		}
+
+		added, deleted := s.added, s.deleted
+		if maxTotal > maxBar {
+			// 按比例缩放，保证有变化的一侧至少显示一个符号
+			added = scaleStat(added, maxTotal, maxBar)
+			deleted = scaleStat(deleted, maxTotal, maxBar)
+		}
+		fmt.Printf(" %-*s | %d %s%s\n", nameWidth, change.Path, s.added+s.deleted,
+			strings.Repeat("+", added), strings.Repeat("-", deleted))
+	}
+
+	fmt.Printf(" %d 个文件被修改，%d 行插入(+)，%d 行删除(-)\n", len(changes), totalAdded, totalDeleted)
+}
+
+func scaleStat(n, total, width int) int {
+	if n == 0 {
+		return 0
+	}
+	scaled := n * width / total
+	if scaled == 0 {
+		scaled = 1
+	}
+	return scaled
+}
+
+// shortHash 返回哈希的前7位，空哈希显示为全零
+func shortHash(hash string) string {
+	if hash == "" {
+		return "0000000"
+	}
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func orDevNull(name string) string {
+	if name == "" {
+		return "/dev/null"
+	}
+	return name
+}
+
+func init() {
+	diffCmd.Flags().Bool("staged", false, "比较暂存区与HEAD")
+	diffCmd.Flags().Bool("cached", false, "--staged 的同义词")
+	diffCmd.Flags().IntP("unified", "U", 3, "差异上下文的行数")
+	diffCmd.Flags().Bool("stat", false, "只显示增删行数统计")
+	diffCmd.Flags().Bool("name-only", false, "只显示变化的文件名")
+}
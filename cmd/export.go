@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "将仓库历史导出为 git fast-import 流",
+	Long: `将所有分支和标签的历史（文件、提交、作者和提交者、附注标签）
+以 git fast-import 流的格式写到标准输出，可以用标准工具转换为Git仓库：
+
+  cit export --format=fast-import | (cd ../project && git init && git fast-import)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "fast-import" {
+			return fmt.Errorf("不支持的导出格式: %s", format)
+		}
+
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库（请先运行 cit init）: %v", err)
+		}
+
+		skipped, err := repo.FastExport(os.Stdout)
+		if err != nil {
+			return fmt.Errorf("导出失败: %v", err)
+		}
+		// 标准输出是导出流，提示写到标准错误
+		for _, name := range skipped {
+			fmt.Fprintf(os.Stderr, "警告: 标签 %s 不指向提交，已跳过\n", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("format", "fast-import", "导出格式，目前只支持 fast-import")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var fastImportCmd = &cobra.Command{
+	Use:   "fast-import",
+	Short: "从标准输入读取 git fast-import 流",
+	Long: `从标准输入读取 git fast-import 流（例如 git fast-export --all 或 cit export 的输出），
+创建其中的文件、提交和标签，并写入分支和标签引用：
+
+  (cd ../project && git fast-export --all) | cit fast-import
+
+当前分支还没有提交时，导入后检出该分支`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库（请先运行 cit init）: %v", err)
+		}
+
+		result, err := repo.FastImport(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("导入失败: %v", err)
+		}
+
+		printImportResult(result)
+		return nil
+	},
+}
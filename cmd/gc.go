@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "打包对象并清理不可达的对象",
+	Long: `将所有可达对象（分支、标签、引用日志、暂存区和合并状态引用的对象）写入一个包文件，
+相似的对象以增量形式保存；随后删除旧的包文件和已经打包的松散对象。
+不可达的对象在超过宽限期（--prune，默认两周）后删除，较新的不可达对象保留为松散对象。
+--prune 接受 now、天数（如 7d）、周数（如 2w）或 Go 的时长写法（如 12h）`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prune, _ := cmd.Flags().GetString("prune")
+		grace, err := parseGracePeriod(prune)
+		if err != nil {
+			return err
+		}
+
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库（请先运行 cit init）: %v", err)
+		}
+
+		result, err := repo.Storage.GC(time.Now().Add(-grace))
+		if err != nil {
+			return fmt.Errorf("垃圾回收失败: %v", err)
+		}
+
+		fmt.Printf("已打包 %d 个对象，其中 %d 个以增量形式保存\n", result.Packed, result.Deltas)
+		fmt.Printf("已删除 %d 个不可达对象", result.Pruned)
+		if result.Kept > 0 {
+			fmt.Printf("，%d 个仍在宽限期内的不可达对象被保留", result.Kept)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+func init() {
+	gcCmd.Flags().String("prune", "2w", "删除早于该时长的不可达对象")
+}
+
+// parseGracePeriod 解析宽限期：now、<n>d、<n>w 或 time.ParseDuration 接受的写法
+func parseGracePeriod(value string) (time.Duration, error) {
+	if value == "now" {
+		return 0, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			if n, err := strconv.Atoi(number); err == nil && n >= 0 {
+				return time.Duration(n) * unit, nil
+			}
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("无效的宽限期: %s", value)
+	}
+	return duration, nil
+}
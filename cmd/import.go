@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <Git仓库路径>",
+	Short: "从本地Git仓库导入历史",
+	Long: `读取本地Git仓库（.git 目录或包含它的工作目录）中的松散对象和包文件，
+将所有分支、标签及其提交、树和文件导入当前仓库，保留作者和时间。
+当前分支还没有提交时，导入后检出源仓库的当前分支`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库（请先运行 cit init）: %v", err)
+		}
+
+		result, err := repo.ImportGit(args[0])
+		if err != nil {
+			return fmt.Errorf("导入失败: %v", err)
+		}
+
+		printImportResult(result)
+		return nil
+	},
+}
+
+// printImportResult 输出导入的分支、标签和被跳过的引用
+func printImportResult(result *git.ImportResult) {
+	fmt.Printf("已导入 %d 个对象，%d 个分支，%d 个标签\n", result.Objects, len(result.Branches), len(result.Tags))
+	for _, name := range result.Branches {
+		fmt.Printf("  分支: %s\n", name)
+	}
+	for _, name := range result.Tags {
+		fmt.Printf("  标签: %s\n", name)
+	}
+	for _, ref := range result.Skipped {
+		fmt.Printf("警告: %s 已存在且指向不同的对象，已跳过\n", ref)
+	}
+	if result.Head != "" {
+		fmt.Printf("已检出分支: %s\n", result.Head)
+	}
+}
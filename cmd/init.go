@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"cit/internal/git"
+	"cit/internal/storage"
 
 	"github.com/spf13/cobra"
 )
@@ -13,8 +14,10 @@ import (
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "初始化一个新的Git仓库",
-	Long:  "在当前目录或指定目录初始化一个新的Git仓库",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `在当前目录或指定目录初始化一个新的Git仓库。
+使用 --format=git 时仓库保存在 .git 目录中，对象和引用与Git逐字节兼容，
+可以直接使用 git log、git fsck 等工具查看`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir := "."
 		if len(args) > 0 {
@@ -32,8 +35,10 @@ var initCmd = &cobra.Command{
 			return fmt.Errorf("目录不存在: %s", absPath)
 		}
 
+		format, _ := cmd.Flags().GetString("format")
+
 		// 初始化仓库
-		repo, err := git.InitRepository(absPath)
+		repo, err := git.InitRepositoryFormat(absPath, format)
 		if err != nil {
 			return fmt.Errorf("初始化仓库失败: %v", err)
 		}
@@ -43,3 +48,7 @@ var initCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	initCmd.Flags().String("format", storage.FormatCit, "仓库格式: cit 或 git")
+}
@@ -12,7 +12,8 @@ import (
 var logCmd = &cobra.Command{
 	Use:   "log",
 	Short: "显示提交历史",
-	Long:  "显示Git仓库的提交历史记录",
+	Long:  "从当前分支（或指定的修订）出发，沿父提交链显示提交历史记录。支持 A..B（B 有而 A 没有的提交）和 A...B（只属于其中一侧的提交）范围",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 查找Git仓库
 		repo, err := git.FindRepository(".")
@@ -20,8 +21,16 @@ var logCmd = &cobra.Command{
 			return fmt.Errorf("未找到Git仓库: %v", err)
 		}
 
+		opts := git.LogOptions{}
+		opts.All, _ = cmd.Flags().GetBool("all")
+		opts.MaxCount, _ = cmd.Flags().GetInt("max-count")
+		opts.FirstParent, _ = cmd.Flags().GetBool("first-parent")
+		if len(args) > 0 {
+			opts.Start = args[0]
+		}
+
 		// 获取提交历史
-		commits, err := repo.GetCommitHistory()
+		commits, err := repo.Log(opts)
 		if err != nil {
 			return fmt.Errorf("获取提交历史失败: %v", err)
 		}
@@ -41,8 +50,8 @@ var logCmd = &cobra.Command{
 			fmt.Printf("信息: %s\n", commit.Message)
 			fmt.Printf("作者: %s\n", commit.Author)
 			fmt.Printf("时间: %s\n", commit.Timestamp)
-			if commit.ParentID != "" {
-				fmt.Printf("父提交: %s\n", commit.ParentID)
+			if len(commit.Parents) > 0 {
+				fmt.Printf("父提交: %s\n", strings.Join(commit.Parents, " "))
 			}
 			if i < len(commits)-1 {
 				fmt.Println(strings.Repeat("-", 40))
@@ -52,3 +61,9 @@ var logCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	logCmd.Flags().Bool("all", false, "显示所有分支的提交")
+	logCmd.Flags().IntP("max-count", "n", 0, "最多显示的提交数")
+	logCmd.Flags().Bool("first-parent", false, "只沿第一个父提交显示历史")
+}
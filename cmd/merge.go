@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"cit/internal/diff"
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [分支名]",
+	Short: "合并指定分支到当前分支",
+	Long: `将指定分支的更改合并到当前分支。可以快进时直接移动分支，否则创建包含两个父提交的合并提交。
+出现冲突时合并会暂停，解决冲突并暂存后使用 cit commit 或 cit merge --continue 完成合并，
+也可以使用 cit merge --abort 放弃合并。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		abort, _ := cmd.Flags().GetBool("abort")
+		cont, _ := cmd.Flags().GetBool("continue")
+		switch {
+		case abort:
+			if err := repo.AbortMerge(); err != nil {
+				return fmt.Errorf("放弃合并失败: %v", err)
+			}
+			fmt.Println("已放弃合并，恢复到合并前的状态")
+			return nil
+		case cont:
+			commit, err := repo.ContinueMerge()
+			if err != nil {
+				return fmt.Errorf("完成合并失败: %v", err)
+			}
+			fmt.Printf("✅ 合并完成\n")
+			fmt.Printf("提交ID: %s\n", commit.ID)
+			return nil
+		case len(args) == 0:
+			return fmt.Errorf("必须指定要合并的分支")
+		}
+
+		sourceBranch := args[0]
+
+		// 检查是否尝试合并到自己
+		if sourceBranch == repo.GetCurrentBranch() {
+			return fmt.Errorf("不能合并分支到自身")
+		}
+
+		opts := git.MergeOptions{}
+		opts.NoFF, _ = cmd.Flags().GetBool("no-ff")
+		opts.Message, _ = cmd.Flags().GetString("message")
+
+		fmt.Printf("正在合并分支 '%s' 到 '%s'...\n", sourceBranch, repo.GetCurrentBranch())
+
+		// 执行合并
+		result, err := repo.MergeBranch(sourceBranch, opts)
+		if err != nil {
+			return fmt.Errorf("合并失败: %v", err)
+		}
+
+		// 显示合并结果
+		if result.Success {
+			fmt.Printf("✅ %s\n", result.Message)
+			if !result.UpToDate {
+				fmt.Printf("提交ID: %s\n", result.CommitID)
+			}
+			for _, file := range result.MergedFiles {
+				fmt.Printf("  %s\n", file)
+			}
+			return nil
+		}
+
+		fmt.Printf("❌ %s\n", result.Message)
+		fmt.Printf("发现 %d 个冲突文件:\n", len(result.Conflicts))
+		for _, conflict := range result.Conflicts {
+			fmt.Printf("  - %s\n", conflict)
+		}
+
+		fmt.Println("\n请使用以下命令解决冲突:")
+		fmt.Printf("  cit resolve <文件路径> <策略>\n")
+		fmt.Printf("  策略选项: ours(保留我们的), theirs(保留他们的), both(保留两者), union(合并去重)\n")
+		fmt.Printf("  例如: cit resolve %s ours，或使用 cit resolve -i %s 逐个选择\n", result.Conflicts[0], result.Conflicts[0])
+		fmt.Println("解决后使用 cit commit 或 cit merge --continue 完成合并，或使用 cit merge --abort 放弃合并")
+
+		// 合并因冲突停止时以非零状态退出，冲突列表和提示已经输出，错误只由 main 输出一次，不再显示用法
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return fmt.Errorf("合并存在 %d 个冲突文件，请解决后提交", len(result.Conflicts))
+	},
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <文件路径> [策略...]",
+	Short: "解决文件冲突",
+	Long: `使用指定策略解决文件中的冲突区块，冲突区块之外的内容保持不变。
+只给出一个策略时应用到所有冲突区块；给出多个策略时依次对应文件中的每个冲突区块。
+使用 -i 为每个冲突区块交互式选择策略。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filePath := args[0]
+		strategies := args[1:]
+
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		if interactive {
+			if strategies, err = promptStrategies(repo, filePath); err != nil {
+				return err
+			}
+		}
+
+		// 验证解决策略
+		if len(strategies) == 0 {
+			return fmt.Errorf("必须指定解决策略，支持: %s", strings.Join(diff.Strategies, ", "))
+		}
+		for _, strategy := range strategies {
+			if !isValidStrategy(strategy) {
+				return fmt.Errorf("无效的解决策略 '%s'，支持: %s", strategy, strings.Join(diff.Strategies, ", "))
+			}
+		}
+
+		// 解决冲突
+		if err := repo.ResolveConflict(filePath, strategies); err != nil {
+			return fmt.Errorf("解决冲突失败: %v", err)
+		}
+
+		fmt.Printf("✅ 已按策略 %s 改写文件 '%s'\n", strings.Join(strategies, ", "), filePath)
+		fmt.Printf("确认内容无误后使用 cit add %s 标记冲突已解决\n", filePath)
+		return nil
+	},
+}
+
+// promptStrategies 逐个显示冲突区块并读取用户选择的策略
+func promptStrategies(repo *git.Repository, filePath string) ([]string, error) {
+	hunks, err := repo.ConflictHunks(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("文件中没有冲突标记，请使用 ours 或 theirs 选择整个文件")
+	}
+
+	shortcuts := map[string]string{"o": diff.ResolveOurs, "t": diff.ResolveTheirs, "b": diff.ResolveBoth, "u": diff.ResolveUnion}
+	reader := bufio.NewReader(os.Stdin)
+	strategies := make([]string, 0, len(hunks))
+
+	for i, hunk := range hunks {
+		fmt.Printf("\n冲突 %d/%d:\n", i+1, len(hunks))
+		printHunkSide("我们的 ("+hunk.OursLabel+")", hunk.Ours)
+		if hunk.Base != nil {
+			printHunkSide("共同祖先 ("+hunk.BaseLabel+")", hunk.Base)
+		}
+		printHunkSide("对方的 ("+hunk.TheirsLabel+")", hunk.Theirs)
+
+		for {
+			fmt.Print("选择 [o]urs / [t]heirs / [b]oth / [u]nion: ")
+			answer, err := reader.ReadString('\n')
+			choice := strings.TrimSpace(answer)
+			if strategy, ok := shortcuts[choice]; ok {
+				choice = strategy
+			}
+			if isValidStrategy(choice) {
+				strategies = append(strategies, choice)
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("读取输入失败: %v", err)
+			}
+			fmt.Println("无效的选择")
+		}
+	}
+
+	return strategies, nil
+}
+
+func printHunkSide(title string, lines []string) {
+	fmt.Printf("--- %s\n", title)
+	for _, line := range lines {
+		fmt.Printf("    %s\n", strings.TrimRight(line, "\n"))
+	}
+}
+
+func isValidStrategy(strategy string) bool {
+	for _, valid := range diff.Strategies {
+		if strategy == valid {
+			return true
+		}
+	}
+	return false
+}
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "显示当前冲突状态",
+	Long:  "列出所有有冲突的文件",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		// 获取冲突状态
+		conflicts, err := repo.GetConflictStatus()
+		if err != nil {
+			return fmt.Errorf("获取冲突状态失败: %v", err)
+		}
+
+		if len(conflicts) == 0 {
+			fmt.Println("✅ 当前没有冲突")
+			return nil
+		}
+
+		entries, err := repo.GetConflicts()
+		if err != nil {
+			return fmt.Errorf("获取冲突状态失败: %v", err)
+		}
+
+		fmt.Printf("发现 %d 个冲突文件:\n", len(conflicts))
+		for _, conflict := range conflicts {
+			fmt.Printf("  - %s (%s)\n", conflict, entries[conflict].Kind())
+		}
+
+		fmt.Println("\n使用以下命令解决冲突:")
+		fmt.Printf("  cit resolve <文件路径> <策略>\n")
+		fmt.Printf("  策略选项: ours(保留我们的), theirs(保留他们的), both(保留两者), union(合并去重)\n")
+		fmt.Println("解决后使用 cit add <文件路径> 标记冲突已解决")
+
+		return nil
+	},
+}
+
+func init() {
+	mergeCmd.Flags().Bool("no-ff", false, "即使可以快进也创建合并提交")
+	mergeCmd.Flags().StringP("message", "m", "", "合并提交信息")
+	mergeCmd.Flags().Bool("abort", false, "放弃进行中的合并")
+	mergeCmd.Flags().Bool("continue", false, "解决冲突后完成合并")
+	resolveCmd.Flags().BoolP("interactive", "i", false, "为每个冲突区块交互式选择策略")
+}
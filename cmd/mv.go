@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <源路径> <目标路径>",
+	Short: "移动或重命名文件",
+	Long: `移动或重命名已跟踪的文件或目录，并在暂存区中记录为旧路径的删除和新路径的添加。
+目标是已存在的目录时，移动到该目录下`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		paths, err := absPaths(args)
+		if err != nil {
+			return err
+		}
+
+		moved, err := repo.MovePath(paths[0], paths[1])
+		if err != nil {
+			return fmt.Errorf("移动失败: %v", err)
+		}
+
+		fmt.Printf("已将 %s 重命名为 %s\n", filepath.Clean(args[0]), moved)
+		return nil
+	},
+}
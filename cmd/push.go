@@ -30,6 +30,9 @@ var pushCmd = &cobra.Command{
 		if len(args) > 1 {
 			branchName = args[1]
 		}
+		if branchName == "" {
+			return fmt.Errorf("HEAD 处于分离状态，请指定要推送的分支")
+		}
 
 		// 检查是否是GitHub推送
 		githubToken, _ := cmd.Flags().GetString("github-token")
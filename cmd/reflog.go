@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var reflogCmd = &cobra.Command{
+	Use:   "reflog [引用]",
+	Short: "显示引用日志",
+	Long: `显示 HEAD 或指定分支的每一次变化，最新的在前。
+第 N 条记录可以用 <引用>@{N} 作为修订使用，例如 cit checkout HEAD@{2}，
+用于找回被合并、重置或切换覆盖掉的提交`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		name := "HEAD"
+		if len(args) > 0 {
+			name = args[0]
+		}
+
+		entries, _, err := repo.Reflog(name)
+		if err != nil {
+			return fmt.Errorf("获取引用日志失败: %v", err)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("暂无引用日志")
+			return nil
+		}
+
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		for i, entry := range entries {
+			fmt.Printf("%s %s@{%d}: %s\n", shortHash(entry.New), name, i, entry.Message)
+			if verbose {
+				fmt.Printf("    %s -> %s  %s  %s\n", shortHash(entry.Old), shortHash(entry.New), entry.Identity, entry.Timestamp)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	reflogCmd.Flags().BoolP("verbose", "v", false, "显示变更前后的提交、操作者和时间")
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset [--soft | --mixed | --hard] [修订] | reset [修订] [--] <路径>...",
+	Short: "将当前分支重置到指定提交，或取消暂存文件",
+	Long: `将当前分支（分离状态下为 HEAD）移动到指定修订（默认 HEAD）：
+  --soft   只移动分支，暂存区和工作目录保持不变
+  --mixed  同时将暂存区重置为目标提交（默认）
+  --hard   同时丢弃工作目录中已跟踪文件的修改
+
+指定路径时不移动分支，只把这些路径在暂存区中的内容恢复为修订（默认 HEAD）中的版本，
+即取消暂存，工作目录不受影响。修订和路径有歧义时用 -- 分隔`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		soft, _ := cmd.Flags().GetBool("soft")
+		mixed, _ := cmd.Flags().GetBool("mixed")
+		hard, _ := cmd.Flags().GetBool("hard")
+
+		modes := 0
+		mode := git.ResetMixed
+		for _, flag := range []struct {
+			set  bool
+			mode git.ResetMode
+		}{{soft, git.ResetSoft}, {mixed, git.ResetMixed}, {hard, git.ResetHard}} {
+			if flag.set {
+				modes++
+				mode = flag.mode
+			}
+		}
+		if modes > 1 {
+			return fmt.Errorf("--soft、--mixed 和 --hard 只能指定一个")
+		}
+
+		// 指定了模式时参数只能是修订，这样无法解析的修订会直接报错而不是被当作路径
+		if modes > 0 && cmd.ArgsLenAtDash() < 0 {
+			if len(args) > 1 {
+				return fmt.Errorf("不能在指定路径时使用 --%s", mode)
+			}
+			var rev string
+			if len(args) == 1 {
+				rev = args[0]
+			}
+			return resetRevision(repo, rev, mode)
+		}
+
+		rev, paths := splitResetArgs(repo, args, cmd.ArgsLenAtDash())
+		if len(paths) == 0 {
+			return resetRevision(repo, rev, mode)
+		}
+		if modes > 0 {
+			return fmt.Errorf("不能在指定路径时使用 --%s", mode)
+		}
+		return resetPaths(repo, rev, paths)
+	},
+}
+
+// resetRevision 将当前分支移动到修订，并按模式重置暂存区和工作目录
+func resetRevision(repo *git.Repository, rev string, mode git.ResetMode) error {
+	commitID, err := repo.Reset(rev, mode)
+	if err != nil {
+		return fmt.Errorf("重置失败: %v", err)
+	}
+
+	commit, err := repo.Storage.GetCommit(commitID)
+	if err != nil {
+		return fmt.Errorf("读取提交失败: %v", err)
+	}
+	fmt.Printf("HEAD 现在位于 %s %s\n", shortHash(commitID), firstLine(commit.Message))
+	return nil
+}
+
+// splitResetArgs 将参数拆分为修订和路径：有 -- 时以它为界，
+// 否则第一个参数能解析为修订时作为修订，其余都是路径
+func splitResetArgs(repo *git.Repository, args []string, dash int) (string, []string) {
+	if dash >= 0 {
+		if dash > 0 {
+			return args[0], args[dash:]
+		}
+		return "", args
+	}
+	if len(args) == 0 {
+		return "", nil
+	}
+	if _, err := repo.ResolveRevision(args[0]); err == nil {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// resetPaths 将路径在暂存区中的内容恢复为修订中的版本
+func resetPaths(repo *git.Repository, rev string, paths []string) error {
+	files, err := absPaths(paths)
+	if err != nil {
+		return err
+	}
+
+	reset, err := repo.ResetPaths(rev, files)
+	if err != nil {
+		return fmt.Errorf("重置失败: %v", err)
+	}
+
+	for _, path := range reset {
+		if rev == "" {
+			fmt.Printf("已取消暂存: %s\n", path)
+		} else {
+			fmt.Printf("已将 %s 的暂存内容重置为 %s 中的版本\n", path, rev)
+		}
+	}
+	return nil
+}
+
+func init() {
+	resetCmd.Flags().Bool("soft", false, "只移动分支，保留暂存区和工作目录")
+	resetCmd.Flags().Bool("mixed", false, "移动分支并重置暂存区（默认）")
+	resetCmd.Flags().Bool("hard", false, "移动分支并重置暂存区和工作目录")
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [--staged] [--worktree] [--source=<修订>] <路径>...",
+	Short: "恢复工作目录或暂存区中的文件",
+	Long: `将文件恢复为来源中的版本：
+  默认恢复工作目录，来源为暂存区，即丢弃尚未暂存的修改
+  --staged 恢复暂存区，来源为 HEAD，即取消暂存
+  同时使用 --staged 和 --worktree 时两者都恢复为 HEAD 中的版本
+  --source 指定来源修订；来源中不存在的已跟踪文件会被删除`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		paths, err := absPaths(args)
+		if err != nil {
+			return err
+		}
+
+		var opts git.RestoreOptions
+		opts.Source, _ = cmd.Flags().GetString("source")
+		opts.Staged, _ = cmd.Flags().GetBool("staged")
+		opts.Worktree, _ = cmd.Flags().GetBool("worktree")
+
+		restored, err := repo.RestorePaths(paths, opts)
+		if err != nil {
+			return fmt.Errorf("恢复失败: %v", err)
+		}
+
+		for _, path := range restored {
+			fmt.Printf("已恢复: %s\n", path)
+		}
+		return nil
+	},
+}
+
+// absPaths 将命令行中的路径转换为绝对路径
+func absPaths(args []string) ([]string, error) {
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, fmt.Errorf("路径错误: %v", err)
+		}
+		paths = append(paths, absPath)
+	}
+	return paths, nil
+}
+
+func init() {
+	restoreCmd.Flags().StringP("source", "s", "", "恢复来源的修订")
+	restoreCmd.Flags().BoolP("staged", "S", false, "恢复暂存区")
+	restoreCmd.Flags().BoolP("worktree", "W", false, "恢复工作目录（默认）")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm [--cached] [-r] [-f] <路径>...",
+	Short: "从暂存区和工作目录中删除文件",
+	Long: `删除已跟踪的文件，并在暂存区中记录删除，下次提交后文件不再出现在快照中。
+使用 --cached 时只从暂存区删除，工作目录中的文件保留为未跟踪文件。
+文件有未提交的修改时拒绝删除，除非使用 -f`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		paths, err := absPaths(args)
+		if err != nil {
+			return err
+		}
+
+		var opts git.RemoveOptions
+		opts.Cached, _ = cmd.Flags().GetBool("cached")
+		opts.Force, _ = cmd.Flags().GetBool("force")
+		opts.Recursive, _ = cmd.Flags().GetBool("recursive")
+
+		removed, err := repo.RemovePaths(paths, opts)
+		if err != nil {
+			return fmt.Errorf("删除失败: %v", err)
+		}
+
+		for _, path := range removed {
+			fmt.Printf("rm '%s'\n", path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rmCmd.Flags().Bool("cached", false, "只从暂存区删除，保留工作目录中的文件")
+	rmCmd.Flags().BoolP("force", "f", false, "忽略未提交的修改，强制删除")
+	rmCmd.Flags().BoolP("recursive", "r", false, "递归删除目录")
+}
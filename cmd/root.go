@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"cit/internal/git"
+
 	"github.com/spf13/cobra"
 )
 
@@ -24,11 +26,29 @@ func init() {
 	// 添加子命令
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(rmCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(restoreCmd)
 	rootCmd.AddCommand(commitCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(reflogCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(catObjectCmd)
 	rootCmd.AddCommand(branchCmd)
 	rootCmd.AddCommand(checkoutCmd)
+	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(resolveCmd)
+	rootCmd.AddCommand(conflictsCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(remoteCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(fastImportCmd)
+	rootCmd.AddCommand(gcCmd)
+
+	rootCmd.PersistentFlags().BoolVar(&git.AllowGitDir, "git", false, "允许操作由Git创建的 .git 仓库")
 }
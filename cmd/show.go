@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"cit/internal/git"
+	"cit/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show [修订 | 修订:路径]",
+	Short: "显示提交或文件内容",
+	Long: `显示对象的内容：
+  cit show <修订>         显示提交信息及其相对第一个父提交的差异
+  cit show <修订>:<路径>   显示该提交中文件的内容或目录的列表`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		rev := repo.CurrentBranch
+		if len(args) > 0 {
+			rev = args[0]
+		}
+
+		if commitRev, filePath, ok := strings.Cut(rev, ":"); ok {
+			if commitRev == "" {
+				commitRev = repo.CurrentBranch
+			}
+			return showPath(repo, commitRev, filePath)
+		}
+
+		// 附注标签先显示标签信息
+		if tag, err := repo.GetTag(rev); err == nil && tag.Tag != nil {
+			fmt.Printf("标签: %s\n", tag.Tag.Name)
+			fmt.Printf("标记者: %s\n", tag.Tag.Tagger)
+			fmt.Printf("时间: %s\n", tag.Tag.Timestamp)
+			fmt.Printf("\n%s\n\n", tag.Tag.Message)
+		}
+
+		commit, err := repo.ResolveCommit(rev)
+		if err != nil {
+			return fmt.Errorf("解析修订失败: %v", err)
+		}
+
+		printCommitHeader(commit)
+
+		changes, err := repo.CommitChanges(commit)
+		if err != nil {
+			return fmt.Errorf("比较差异失败: %v", err)
+		}
+
+		context, _ := cmd.Flags().GetInt("unified")
+		if len(changes) > 0 {
+			fmt.Println()
+		}
+		for _, change := range changes {
+			if err := printFileDiff(change, context); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// printCommitHeader 输出提交的元数据和缩进后的提交信息
+func printCommitHeader(commit *storage.Commit) {
+	fmt.Printf("提交: %s\n", commit.ID)
+	if commit.IsMerge() {
+		fmt.Printf("合并: %s\n", strings.Join(commit.Parents, " "))
+	}
+	fmt.Printf("作者: %s\n", commit.Author)
+	fmt.Printf("时间: %s\n", commit.Timestamp)
+	fmt.Println()
+	for _, line := range strings.Split(commit.Message, "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+}
+
+// showPath 输出修订中文件的内容，目录则列出其中的条目
+func showPath(repo *git.Repository, rev, filePath string) error {
+	entry, err := repo.ResolvePath(rev, filePath)
+	if err != nil {
+		return err
+	}
+
+	if !entry.IsTree() {
+		content, err := repo.Storage.GetObject(entry.Hash)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+
+	tree, err := repo.Storage.GetTree(entry.Hash)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("tree %s:%s\n\n", rev, filePath)
+	for _, child := range tree.Entries {
+		if child.IsTree() {
+			fmt.Printf("%s/\n", child.Name)
+		} else {
+			fmt.Println(child.Name)
+		}
+	}
+	return nil
+}
+
+func init() {
+	showCmd.Flags().IntP("unified", "U", 3, "差异上下文的行数")
+}
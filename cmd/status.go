@@ -27,21 +27,35 @@ var statusCmd = &cobra.Command{
 
 		// 显示状态
 		fmt.Println("仓库状态:")
-		fmt.Printf("当前分支: %s\n", status.CurrentBranch)
+		if status.Detached {
+			fmt.Printf("HEAD 处于分离状态，位于 %s\n", status.LastCommit)
+		} else {
+			fmt.Printf("当前分支: %s\n", status.CurrentBranch)
+		}
 		fmt.Printf("最新提交: %s\n", status.LastCommit)
+		if status.MergeHead != "" {
+			fmt.Printf("正在合并: %s（解决冲突后运行 cit commit 完成合并）\n", status.MergeHead)
+		}
 		
-		if len(status.StagedFiles) > 0 {
-			fmt.Println("\n暂存区文件:")
-			for _, file := range status.StagedFiles {
-				fmt.Printf("  %s\n", file)
+		if len(status.Conflicts) > 0 {
+			fmt.Println("\n未合并的路径（解决冲突后使用 cit add 标记为已解决）:")
+			for _, file := range status.Conflicts {
+				fmt.Printf("  冲突: %s\n", file)
 			}
 		}
 
-		if len(status.ModifiedFiles) > 0 {
+		if len(status.StagedNew)+len(status.StagedModified)+len(status.StagedDeleted)+len(status.StagedRenamed) > 0 {
+			fmt.Println("\n暂存区文件:")
+			printStatusFiles("新文件", status.StagedNew)
+			printStatusFiles("已修改", status.StagedModified)
+			printStatusFiles("已删除", status.StagedDeleted)
+			printStatusFiles("已重命名", status.StagedRenamed)
+		}
+
+		if len(status.ModifiedFiles)+len(status.DeletedFiles) > 0 {
 			fmt.Println("\n已修改但未暂存的文件:")
-			for _, file := range status.ModifiedFiles {
-				fmt.Printf("  %s\n", file)
-			}
+			printStatusFiles("已修改", status.ModifiedFiles)
+			printStatusFiles("已删除", status.DeletedFiles)
 		}
 
 		if len(status.UntrackedFiles) > 0 {
@@ -51,6 +65,17 @@ var statusCmd = &cobra.Command{
 			}
 		}
 
+		if status.IsClean() {
+			fmt.Println("\n工作目录干净，没有需要提交的内容")
+		}
+
 		return nil
 	},
 }
+
+// printStatusFiles 按类别输出文件列表
+func printStatusFiles(label string, files []string) {
+	for _, file := range files {
+		fmt.Printf("  %s: %s\n", label, file)
+	}
+}
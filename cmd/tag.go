@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cit/internal/git"
+
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [标签名] [修订]",
+	Short: "创建、列出或删除标签",
+	Long: `管理标签：
+  cit tag                       列出所有标签
+  cit tag -l <模式>              列出名称匹配通配符模式的标签
+  cit tag <标签名> [修订]          在修订（默认HEAD）上创建轻量标签
+  cit tag -a <标签名> -m <说明>    创建附注标签
+  cit tag -d <标签名>             删除标签`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 查找Git仓库
+		repo, err := git.FindRepository(".")
+		if err != nil {
+			return fmt.Errorf("未找到Git仓库: %v", err)
+		}
+
+		list, _ := cmd.Flags().GetBool("list")
+		del, _ := cmd.Flags().GetBool("delete")
+		annotate, _ := cmd.Flags().GetBool("annotate")
+		message, _ := cmd.Flags().GetString("message")
+
+		if del {
+			if len(args) == 0 {
+				return fmt.Errorf("请指定要删除的标签")
+			}
+			for _, name := range args {
+				tag, err := repo.GetTag(name)
+				if err != nil {
+					return err
+				}
+				if err := repo.DeleteTag(name); err != nil {
+					return err
+				}
+				fmt.Printf("已删除标签 '%s'（曾指向 %s）\n", name, shortHash(tag.Commit))
+			}
+			return nil
+		}
+
+		if list || len(args) == 0 {
+			var pattern string
+			if len(args) > 0 {
+				pattern = args[0]
+			}
+			tags, err := repo.ListTags(pattern)
+			if err != nil {
+				return fmt.Errorf("获取标签列表失败: %v", err)
+			}
+			for _, tag := range tags {
+				fmt.Println(tag.Name)
+			}
+			return nil
+		}
+
+		// -m 隐含 -a
+		annotate = annotate || message != ""
+
+		var rev string
+		if len(args) > 1 {
+			rev = args[1]
+		}
+
+		tag, err := repo.CreateTag(args[0], rev, message, annotate)
+		if err != nil {
+			return fmt.Errorf("创建标签失败: %v", err)
+		}
+
+		if tag.Tag != nil {
+			fmt.Printf("已创建附注标签 '%s'，指向 %s\n", tag.Name, shortHash(tag.Commit))
+		} else {
+			fmt.Printf("已创建标签 '%s'，指向 %s\n", tag.Name, shortHash(tag.Commit))
+		}
+		return nil
+	},
+}
+
+func init() {
+	tagCmd.Flags().BoolP("list", "l", false, "列出标签，可以指定通配符模式")
+	tagCmd.Flags().BoolP("delete", "d", false, "删除标签")
+	tagCmd.Flags().BoolP("annotate", "a", false, "创建附注标签")
+	tagCmd.Flags().StringP("message", "m", "", "附注标签的说明")
+}
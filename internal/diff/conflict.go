@@ -0,0 +1,200 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// 冲突解决策略
+const (
+	ResolveOurs   = "ours"   // 保留我们的内容
+	ResolveTheirs = "theirs" // 保留对方的内容
+	ResolveBoth   = "both"   // 先保留我们的内容，再保留对方的内容
+	ResolveUnion  = "union"  // 同 both，但去掉对方内容中与我们重复的行
+)
+
+// Strategies 是所有支持的冲突解决策略
+var Strategies = []string{ResolveOurs, ResolveTheirs, ResolveBoth, ResolveUnion}
+
+// Conflict 表示文件中的一个冲突区块
+type Conflict struct {
+	OursLabel   string
+	BaseLabel   string
+	TheirsLabel string
+	Ours        []string
+	Base        []string // 没有 ||||||| 区块时为 nil
+	Theirs      []string
+}
+
+// Segment 是冲突文件的一个片段：普通文本或冲突区块
+type Segment struct {
+	Lines    []string
+	Conflict *Conflict
+}
+
+// ParseConflicts 将带冲突标记的内容切分为普通文本和冲突区块。
+// 普通文本原样保留，因此任意多个冲突区块都能被独立解决。
+func ParseConflicts(data []byte) ([]*Segment, error) {
+	const (
+		stateText = iota
+		stateOurs
+		stateBase
+		stateTheirs
+	)
+
+	var segments []*Segment
+	var text []string
+	var current *Conflict
+	state := stateText
+
+	flushText := func() {
+		if len(text) > 0 {
+			segments = append(segments, &Segment{Lines: text})
+			text = nil
+		}
+	}
+
+	for i, line := range SplitLines(data) {
+		switch state {
+		case stateText:
+			if label, ok := markerLabel(line, "<<<<<<<"); ok {
+				flushText()
+				current = &Conflict{OursLabel: label}
+				state = stateOurs
+				continue
+			}
+			text = append(text, line)
+		case stateOurs:
+			if label, ok := markerLabel(line, "|||||||"); ok {
+				current.BaseLabel = label
+				current.Base = []string{}
+				state = stateBase
+				continue
+			}
+			if _, ok := markerLabel(line, "======="); ok {
+				state = stateTheirs
+				continue
+			}
+			current.Ours = append(current.Ours, line)
+		case stateBase:
+			if _, ok := markerLabel(line, "======="); ok {
+				state = stateTheirs
+				continue
+			}
+			current.Base = append(current.Base, line)
+		case stateTheirs:
+			if label, ok := markerLabel(line, ">>>>>>>"); ok {
+				current.TheirsLabel = label
+				segments = append(segments, &Segment{Conflict: current})
+				current = nil
+				state = stateText
+				continue
+			}
+			if _, ok := markerLabel(line, "<<<<<<<"); ok {
+				return nil, fmt.Errorf("第 %d 行: 冲突区块嵌套", i+1)
+			}
+			current.Theirs = append(current.Theirs, line)
+		}
+	}
+
+	if state != stateText {
+		return nil, fmt.Errorf("冲突区块没有结束标记")
+	}
+	flushText()
+	return segments, nil
+}
+
+// Conflicts 返回片段中的所有冲突区块
+func Conflicts(segments []*Segment) []*Conflict {
+	var conflicts []*Conflict
+	for _, segment := range segments {
+		if segment.Conflict != nil {
+			conflicts = append(conflicts, segment.Conflict)
+		}
+	}
+	return conflicts
+}
+
+// Resolve 按策略返回冲突区块解决后的内容
+func (c *Conflict) Resolve(strategy string) ([]string, error) {
+	switch strategy {
+	case ResolveOurs:
+		return c.Ours, nil
+	case ResolveTheirs:
+		return c.Theirs, nil
+	case ResolveBoth:
+		return joinBlocks(c.Ours, c.Theirs), nil
+	case ResolveUnion:
+		seen := make(map[string]bool, len(c.Ours))
+		for _, line := range c.Ours {
+			seen[strings.TrimSuffix(line, "\n")] = true
+		}
+		var extra []string
+		for _, line := range c.Theirs {
+			if !seen[strings.TrimSuffix(line, "\n")] {
+				extra = append(extra, line)
+			}
+		}
+		return joinBlocks(c.Ours, extra), nil
+	default:
+		return nil, fmt.Errorf("不支持的解决策略: %s", strategy)
+	}
+}
+
+// ResolveSegments 按顺序为每个冲突区块应用一个策略，返回解决后的完整内容。
+// 只提供一个策略时应用到所有冲突区块。
+func ResolveSegments(segments []*Segment, strategies []string) ([]byte, error) {
+	conflicts := len(Conflicts(segments))
+	if len(strategies) != 1 && len(strategies) != conflicts {
+		return nil, fmt.Errorf("文件有 %d 个冲突区块，但提供了 %d 个策略", conflicts, len(strategies))
+	}
+
+	var buf bytes.Buffer
+	index := 0
+	for _, segment := range segments {
+		lines := segment.Lines
+		if segment.Conflict != nil {
+			strategy := strategies[0]
+			if len(strategies) > 1 {
+				strategy = strategies[index]
+			}
+			index++
+
+			var err error
+			if lines, err = segment.Conflict.Resolve(strategy); err != nil {
+				return nil, err
+			}
+		}
+		for _, line := range lines {
+			buf.WriteString(line)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// markerLabel 判断行是否为指定的冲突标记，并返回标记后的标签
+func markerLabel(line, marker string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, marker) {
+		return "", false
+	}
+	rest := line[len(marker):]
+	if rest == "" {
+		return "", true
+	}
+	if rest[0] != ' ' {
+		return "", false
+	}
+	return rest[1:], true
+}
+
+// joinBlocks 拼接两段内容，保证第一段以换行结尾
+func joinBlocks(first, second []string) []string {
+	result := make([]string, 0, len(first)+len(second))
+	result = append(result, first...)
+	if n := len(result); n > 0 && len(second) > 0 && !strings.HasSuffix(result[n-1], "\n") {
+		result[n-1] += "\n"
+	}
+	return append(result, second...)
+}
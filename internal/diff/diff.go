@@ -0,0 +1,223 @@
+package diff
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// Op 表示编辑操作的类型
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Edit 表示编辑脚本中的一行。
+// OldLine/NewLine 是该行在旧/新文本中的下标（从0开始），不适用时为 -1。
+type Edit struct {
+	Op      Op
+	OldLine int
+	NewLine int
+	Text    string
+}
+
+// SplitLines 按行切分文本，每行保留结尾的换行符，
+// 因此最后一行没有换行符的情况也能被准确还原。
+func SplitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// IsBinary 判断内容是否为二进制：前8000字节中包含NUL字节即视为二进制，与Git的判断方式一致
+func IsBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// Lines 使用 Myers 算法计算从 a 到 b 的最短编辑脚本。采用线性空间的分治版本：
+// 每次找出编辑路径中间的一段对角线（middle snake），再分别处理它前后的部分，
+// 内存只与行数成正比，完全改写的大文件也不会耗尽内存。
+func Lines(a, b []string) []Edit {
+	if len(a)+len(b) == 0 {
+		return nil
+	}
+
+	// 将每行映射为整数，比较时不必逐字节比较字符串
+	ids := make(map[string]int)
+	lineIDs := func(lines []string) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+
+	n, m := len(a), len(b)
+	size := 2*(n+m) + 2
+	d := &differ{
+		a:     a,
+		b:     b,
+		aIDs:  lineIDs(a),
+		bIDs:  lineIDs(b),
+		vf:    make([]int, size),
+		vb:    make([]int, size),
+		edits: make([]Edit, 0, n+m),
+	}
+	d.compare(0, n, 0, m)
+	return groupChanges(d.edits)
+}
+
+// differ 保存一次比较的状态，vf/vb 是正向和反向搜索的工作数组，在递归中复用
+type differ struct {
+	a, b       []string
+	aIDs, bIDs []int
+	vf, vb     []int
+	edits      []Edit
+}
+
+// compare 输出 a[aLo:aHi] 到 b[bLo:bHi] 的编辑脚本
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// 去掉公共前缀和后缀
+	for aLo < aHi && bLo < bHi && d.aIDs[aLo] == d.bIDs[bLo] {
+		d.equal(aLo, bLo)
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.aIDs[aHi-suffix-1] == d.bIDs[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, Edit{Op: Insert, OldLine: -1, NewLine: y, Text: d.b[y]})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, Edit{Op: Delete, OldLine: x, NewLine: -1, Text: d.a[x]})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.equal(x, y)
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.equal(aHi+i, bHi+i)
+	}
+}
+
+func (d *differ) equal(x, y int) {
+	d.edits = append(d.edits, Edit{Op: Equal, OldLine: x, NewLine: y, Text: d.a[x]})
+}
+
+// middleSnake 同时从起点正向、从终点反向搜索，两者在某条对角线上相遇时，
+// 返回相遇处对角线段的起点 (x, y) 和终点 (u, v)，均为绝对下标。
+// 调用方保证两段都不为空，且首尾行不相同。
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	a, b := d.aIDs[aLo:aHi], d.bIDs[bLo:bHi]
+	n, m := len(a), len(b)
+	// 反向搜索在倒序的序列上进行，倒序中的对角线 k 对应正向的对角线 delta-k
+	delta := n - m
+	odd := delta%2 != 0
+	offset := n + m + 1
+	vf, vb := d.vf, d.vb
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for step := 0; step <= (n+m+1)/2; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			if rk := delta - k; odd && rk >= -(step-1) && rk <= step-1 && x+vb[offset+rk] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			if fk := delta - k; !odd && fk >= -step && fk <= step && x+vf[offset+fk] >= n {
+				return aLo + n - x, bLo + m - y, aLo + n - x0, bLo + m - y0
+			}
+		}
+	}
+
+	// 两个方向的搜索必然相遇，不会到达这里
+	panic("diff: middle snake not found")
+}
+
+// groupChanges 将每段连续的修改整理为先删除后插入，与 Git 输出修改区块的方式一致
+func groupChanges(edits []Edit) []Edit {
+	for start := 0; start < len(edits); {
+		if edits[start].Op == Equal {
+			start++
+			continue
+		}
+		end := start
+		for end < len(edits) && edits[end].Op != Equal {
+			end++
+		}
+		sort.SliceStable(edits[start:end], func(i, j int) bool {
+			return edits[start+i].Op == Delete && edits[start+j].Op == Insert
+		})
+		start = end
+	}
+	return edits
+}
+
+// matches 返回 a 中每一行在 b 中匹配的行号，未匹配为 -1
+func matches(a, b []string) []int {
+	result := make([]int, len(a))
+	for i := range result {
+		result[i] = -1
+	}
+	for _, edit := range Lines(a, b) {
+		if edit.Op == Equal {
+			result[edit.OldLine] = edit.NewLine
+		}
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// checkEdits 检查编辑脚本能还原出两段文本，并返回其中的修改行数
+func checkEdits(t *testing.T, a, b []string, edits []Edit) int {
+	t.Helper()
+	var oldLines, newLines []string
+	changes := 0
+	for _, edit := range edits {
+		switch edit.Op {
+		case Equal:
+			if a[edit.OldLine] != edit.Text || b[edit.NewLine] != edit.Text {
+				t.Fatalf("相同行的内容不一致: %+v", edit)
+			}
+			oldLines = append(oldLines, edit.Text)
+			newLines = append(newLines, edit.Text)
+		case Delete:
+			if a[edit.OldLine] != edit.Text || len(oldLines) != edit.OldLine {
+				t.Fatalf("删除行错误: %+v", edit)
+			}
+			oldLines = append(oldLines, edit.Text)
+			changes++
+		case Insert:
+			if b[edit.NewLine] != edit.Text || len(newLines) != edit.NewLine {
+				t.Fatalf("插入行错误: %+v", edit)
+			}
+			newLines = append(newLines, edit.Text)
+			changes++
+		}
+	}
+	if strings.Join(oldLines, "") != strings.Join(a, "") || strings.Join(newLines, "") != strings.Join(b, "") {
+		t.Fatalf("编辑脚本不能还原原文\na=%q\nb=%q\nedits=%+v", a, b, edits)
+	}
+	return changes
+}
+
+// lcsLength 用动态规划计算最长公共子序列的长度，用于验证编辑脚本最短
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestLines(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"", ""},
+		{"", "a\nb\n"},
+		{"a\nb\n", ""},
+		{"a\nb\nc\n", "a\nb\nc\n"},
+		{"a\nb\nc\n", "a\nx\nc\n"},
+		{"a\nb\nc\nd\n", "b\nc\nd\ne\n"},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+		{"a\nb", "a\nb\n"},
+	}
+	for _, c := range cases {
+		a, b := SplitLines([]byte(c.a)), SplitLines([]byte(c.b))
+		changes := checkEdits(t, a, b, Lines(a, b))
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changes != want {
+			t.Errorf("%q -> %q: 修改 %d 行，最少为 %d 行", c.a, c.b, changes, want)
+		}
+	}
+}
+
+func TestLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(40))
+		for i := range lines {
+			lines[i] = fmt.Sprintf("%d\n", rng.Intn(5))
+		}
+		return lines
+	}
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		changes := checkEdits(t, a, b, Lines(a, b))
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changes != want {
+			t.Fatalf("%q -> %q: 修改 %d 行，最少为 %d 行", a, b, changes, want)
+		}
+	}
+}
+
+func TestLinesGroupsChanges(t *testing.T) {
+	a := SplitLines([]byte("1\n2\n3\nsame\n"))
+	b := SplitLines([]byte("x\ny\nz\nsame\n"))
+	var ops []Op
+	for _, edit := range Lines(a, b) {
+		ops = append(ops, edit.Op)
+	}
+	want := []Op{Delete, Delete, Delete, Insert, Insert, Insert, Equal}
+	if fmt.Sprint(ops) != fmt.Sprint(want) {
+		t.Fatalf("修改区块应先删除后插入，实际 %v", ops)
+	}
+}
+
+func TestLinesFullRewrite(t *testing.T) {
+	const n = 8000
+	a, b := make([]string, n), make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+	if changes := checkEdits(t, a, b, Lines(a, b)); changes != 2*n {
+		t.Fatalf("完全改写应修改 %d 行，实际 %d 行", 2*n, changes)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	labels := Labels{Ours: "ours", Base: "base", Theirs: "theirs"}
+	cases := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          int
+	}{
+		{"无修改", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", 0},
+		{"只有我们修改", "a\nb\nc\n", "a\nB\nc\n", "a\nb\nc\n", "a\nB\nc\n", 0},
+		{"只有对方修改", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nC\n", "a\nb\nC\n", 0},
+		{"互不重叠", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", 0},
+		{"相同修改", "a\nb\n", "a\nx\n", "a\nx\n", "a\nx\n", 0},
+		{
+			"冲突", "a\nb\nc\n", "a\nours\nc\n", "a\ntheirs\nc\n",
+			"a\n<<<<<<< ours\nours\n||||||| base\nb\n=======\ntheirs\n>>>>>>> theirs\nc\n", 1,
+		},
+		{
+			"缺少结尾换行", "a\nb", "a\nx", "a\ny",
+			"a\n<<<<<<< ours\nx\n||||||| base\nb\n=======\ny\n>>>>>>> theirs\n", 1,
+		},
+	}
+	for _, c := range cases {
+		result := Merge3([]byte(c.base), []byte(c.ours), []byte(c.theirs), labels)
+		if string(result.Content) != c.want || result.Conflicts != c.conflicts {
+			t.Errorf("%s: 得到 %d 处冲突\n%s\n期望 %d 处冲突\n%s", c.name, result.Conflicts, result.Content, c.conflicts, c.want)
+		}
+	}
+}
+
+func TestMerge3FullRewrite(t *testing.T) {
+	const n = 8000
+	var base, ours strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&base, "line %d\n", i)
+		fmt.Fprintf(&ours, "rewritten %d\n", i)
+	}
+	result := Merge3([]byte(base.String()), []byte(ours.String()), []byte(base.String()), Labels{})
+	if result.Conflicts != 0 || string(result.Content) != ours.String() {
+		t.Fatalf("只有一方完全改写时应直接采用改写后的内容，冲突 %d 处", result.Conflicts)
+	}
+}
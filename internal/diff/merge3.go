@@ -0,0 +1,119 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Labels 是冲突标记中显示的三方名称
+type Labels struct {
+	Ours   string
+	Base   string
+	Theirs string
+}
+
+// MergeResult 是三方合并的结果
+type MergeResult struct {
+	Content   []byte
+	Conflicts int // 冲突区块数
+}
+
+// Merge3 对文本做 diff3 风格的三方合并。
+// 双方修改互不重叠的区块会自动合并；只有重叠的区块才写入冲突标记：
+//
+//	<<<<<<< ours
+//	我们的内容
+//	||||||| base
+//	共同祖先的内容
+//	=======
+//	对方的内容
+//	>>>>>>> theirs
+func Merge3(base, ours, theirs []byte, labels Labels) *MergeResult {
+	o, a, b := SplitLines(base), SplitLines(ours), SplitLines(theirs)
+	matchA, matchB := matches(o, a), matches(o, b)
+
+	result := &MergeResult{}
+	var buf bytes.Buffer
+	writeLines := func(lines []string) {
+		for _, line := range lines {
+			buf.WriteString(line)
+		}
+	}
+
+	lo, ao, bo := 0, 0, 0
+	for {
+		// 三方一致的稳定区块
+		i := 0
+		for lo+i < len(o) && matchA[lo+i] == ao+i && matchB[lo+i] == bo+i {
+			i++
+		}
+		if i > 0 {
+			writeLines(o[lo : lo+i])
+			lo, ao, bo = lo+i, ao+i, bo+i
+			continue
+		}
+
+		// 寻找下一个三方都匹配的同步点
+		next := -1
+		for j := lo; j < len(o); j++ {
+			if matchA[j] >= ao && matchB[j] >= bo {
+				next = j
+				break
+			}
+		}
+
+		oEnd, aEnd, bEnd := len(o), len(a), len(b)
+		if next >= 0 {
+			oEnd, aEnd, bEnd = next, matchA[next], matchB[next]
+		}
+
+		chunkO, chunkA, chunkB := o[lo:oEnd], a[ao:aEnd], b[bo:bEnd]
+		switch {
+		case equalLines(chunkA, chunkB), equalLines(chunkO, chunkB):
+			// 双方修改相同，或只有我们修改
+			writeLines(chunkA)
+		case equalLines(chunkO, chunkA):
+			// 只有对方修改
+			writeLines(chunkB)
+		default:
+			result.Conflicts++
+			fmt.Fprintf(&buf, "<<<<<<< %s\n", labels.Ours)
+			writeConflictLines(&buf, chunkA)
+			fmt.Fprintf(&buf, "||||||| %s\n", labels.Base)
+			writeConflictLines(&buf, chunkO)
+			buf.WriteString("=======\n")
+			writeConflictLines(&buf, chunkB)
+			fmt.Fprintf(&buf, ">>>>>>> %s\n", labels.Theirs)
+		}
+
+		if next < 0 {
+			break
+		}
+		lo, ao, bo = oEnd, aEnd, bEnd
+	}
+
+	result.Content = buf.Bytes()
+	return result
+}
+
+// writeConflictLines 写入冲突区块的内容，保证标记总是从新行开始
+func writeConflictLines(buf *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+	if n := len(lines); n > 0 && lines[n-1][len(lines[n-1])-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Hunk 是统一格式差异中的一个区块
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Edits    []Edit
+}
+
+// Hunks 将编辑脚本按上下文行数分组为区块，相距不超过 2*context 行的修改合并到同一区块
+func Hunks(edits []Edit, context int) []*Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks []*Hunk
+	n := len(edits)
+	for i := 0; i < n; {
+		// 找到下一处修改
+		for i < n && edits[i].Op == Equal {
+			i++
+		}
+		if i == n {
+			break
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		if len(hunks) > 0 {
+			if prevEnd := hunkEnd(edits, hunks[len(hunks)-1]); start < prevEnd {
+				start = prevEnd
+			}
+		}
+
+		// 向后扩展，直到相等行的间隔足够长
+		last := i
+		for j := i; j < n; {
+			if edits[j].Op != Equal {
+				last = j
+				j++
+				continue
+			}
+			k := j
+			for k < n && edits[k].Op == Equal {
+				k++
+			}
+			if k == n || k-j > 2*context {
+				break
+			}
+			j = k
+		}
+
+		end := last + 1 + context
+		if end > n {
+			end = n
+		}
+
+		hunks = append(hunks, newHunk(edits, start, end))
+		i = end
+	}
+
+	return hunks
+}
+
+// hunkEnd 返回区块在编辑脚本中的结束位置
+func hunkEnd(edits []Edit, hunk *Hunk) int {
+	last := hunk.Edits[len(hunk.Edits)-1]
+	for i := len(edits) - 1; i >= 0; i-- {
+		if edits[i] == last {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func newHunk(edits []Edit, start, end int) *Hunk {
+	oldPos, newPos := 0, 0
+	for _, edit := range edits[:start] {
+		if edit.Op != Insert {
+			oldPos++
+		}
+		if edit.Op != Delete {
+			newPos++
+		}
+	}
+
+	hunk := &Hunk{Edits: edits[start:end]}
+	for _, edit := range hunk.Edits {
+		if edit.Op != Insert {
+			hunk.OldLines++
+		}
+		if edit.Op != Delete {
+			hunk.NewLines++
+		}
+	}
+
+	// 与 diff -u 一致：区块为空的一侧从前一行开始计数
+	hunk.OldStart, hunk.NewStart = oldPos, newPos
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+	return hunk
+}
+
+// Header 返回区块头，例如 "@@ -1,3 +1,4 @@"
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", formatRange(h.OldStart, h.OldLines), formatRange(h.NewStart, h.NewLines))
+}
+
+func formatRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// WriteUnified 以统一格式输出 old 到 new 的差异，oldName/newName 为空时输出 /dev/null
+func WriteUnified(w io.Writer, oldName, newName string, old, new []byte, context int) error {
+	edits := Lines(SplitLines(old), SplitLines(new))
+	hunks := Hunks(edits, context)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	if oldName == "" {
+		oldName = "/dev/null"
+	}
+	if newName == "" {
+		newName = "/dev/null"
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName); err != nil {
+		return err
+	}
+
+	for _, hunk := range hunks {
+		if _, err := fmt.Fprintln(w, hunk.Header()); err != nil {
+			return err
+		}
+		for _, edit := range hunk.Edits {
+			prefix := " "
+			switch edit.Op {
+			case Insert:
+				prefix = "+"
+			case Delete:
+				prefix = "-"
+			}
+
+			line := prefix + edit.Text
+			if !strings.HasSuffix(edit.Text, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Count 统计从 old 到 new 增加和删除的行数
+func Count(old, new []byte) (added, deleted int) {
+	for _, edit := range Lines(SplitLines(old), SplitLines(new)) {
+		switch edit.Op {
+		case Insert:
+			added++
+		case Delete:
+			deleted++
+		}
+	}
+	return added, deleted
+}
@@ -0,0 +1,176 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"cit/internal/storage"
+)
+
+// BranchInfo 描述分支的详细信息，用于 branch -v/-vv
+type BranchInfo struct {
+	Name     string
+	Head     string
+	Current  bool
+	Subject  string // 分支头提交信息的第一行
+	Upstream string // 跟踪的上游分支，没有设置时为空
+	Gone     bool   // 上游分支已不存在
+	Ahead    int    // 分支有而上游没有的提交数
+	Behind   int    // 上游有而分支没有的提交数
+}
+
+// DeleteBranch 删除分支，返回分支被删除前指向的提交。
+// 非强制模式下，分支必须已经合并到上游分支（没有上游时为 HEAD）。
+func (r *Repository) DeleteBranch(name string, force bool) (string, error) {
+	if name == r.CurrentBranch {
+		return "", fmt.Errorf("无法删除当前所在的分支 '%s'", name)
+	}
+
+	head, err := r.Storage.GetBranchHead(name)
+	if err != nil {
+		return "", err
+	}
+
+	if !force {
+		target := "HEAD"
+		if upstream, err := r.Storage.GetUpstream(name); err == nil && upstream != "" {
+			target = upstream
+		}
+
+		targetID, err := r.ResolveRevision(target)
+		if err != nil {
+			return "", fmt.Errorf("无法检查分支是否已合并: %v", err)
+		}
+		merged, err := r.IsAncestor(head, targetID)
+		if err != nil {
+			return "", err
+		}
+		if !merged {
+			return "", fmt.Errorf("分支 '%s' 尚未合并到 %s，如确定要删除请使用 -D", name, target)
+		}
+	}
+
+	if err := r.Storage.DeleteBranch(name); err != nil {
+		return "", err
+	}
+	return head, nil
+}
+
+// RenameBranch 重命名分支，oldName 为空时重命名当前分支
+func (r *Repository) RenameBranch(oldName, newName string) error {
+	if oldName == "" {
+		if r.IsDetached() {
+			return fmt.Errorf("HEAD 处于分离状态，请指定要重命名的分支")
+		}
+		oldName = r.CurrentBranch
+	}
+
+	if err := r.Storage.RenameBranch(oldName, newName); err != nil {
+		return err
+	}
+	if r.CurrentBranch == oldName {
+		r.CurrentBranch = newName
+	}
+
+	head, err := r.Storage.GetBranchHead(newName)
+	if err != nil || head == "" {
+		return err
+	}
+	reason := fmt.Sprintf("Branch: renamed %s to %s", storage.BranchRef(oldName), storage.BranchRef(newName))
+	return r.Storage.AppendReflog(storage.BranchRef(newName), r.reflogEntry(head, head, reason))
+}
+
+// SetUpstream 设置分支跟踪的上游分支，upstream 为空时取消跟踪
+func (r *Repository) SetUpstream(branch, upstream string) error {
+	if branch == "" {
+		if r.IsDetached() {
+			return fmt.Errorf("HEAD 处于分离状态，请指定分支")
+		}
+		branch = r.CurrentBranch
+	}
+	if _, err := r.Storage.GetBranchHead(branch); err != nil {
+		return err
+	}
+
+	if upstream != "" {
+		if upstream == branch {
+			return fmt.Errorf("分支不能跟踪自身")
+		}
+		if _, err := r.ResolveRevision(upstream); err != nil {
+			return fmt.Errorf("上游分支无效: %v", err)
+		}
+	}
+	return r.Storage.SetUpstream(branch, upstream)
+}
+
+// BranchDetails 返回所有分支的详细信息，包括上游分支和领先/落后的提交数
+func (r *Repository) BranchDetails() ([]*BranchInfo, error) {
+	branches, err := r.Storage.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*BranchInfo, 0, len(branches))
+	for _, branch := range branches {
+		info := &BranchInfo{
+			Name:    branch.Name,
+			Head:    branch.Head,
+			Current: branch.Name == r.CurrentBranch,
+		}
+
+		if branch.Head != "" {
+			commit, err := r.Storage.GetCommit(branch.Head)
+			if err != nil {
+				return nil, err
+			}
+			info.Subject = firstLine(commit.Message)
+		}
+
+		if info.Upstream, err = r.Storage.GetUpstream(branch.Name); err != nil {
+			return nil, err
+		}
+		if info.Upstream != "" {
+			upstreamID, err := r.ResolveRevision(info.Upstream)
+			if err != nil {
+				info.Gone = true
+			} else if info.Ahead, info.Behind, err = r.AheadBehind(branch.Head, upstreamID); err != nil {
+				return nil, err
+			}
+		}
+
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// AheadBehind 计算 local 相对 upstream 领先和落后的提交数
+func (r *Repository) AheadBehind(local, upstream string) (ahead, behind int, err error) {
+	localAncestors := make(map[string]bool)
+	if local != "" {
+		if localAncestors, err = r.ancestors(local); err != nil {
+			return 0, 0, err
+		}
+	}
+	upstreamAncestors, err := r.ancestors(upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for id := range localAncestors {
+		if !upstreamAncestors[id] {
+			ahead++
+		}
+	}
+	for id := range upstreamAncestors {
+		if !localAncestors[id] {
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// firstLine 返回提交信息的第一行
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
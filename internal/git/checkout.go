@@ -0,0 +1,224 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cit/internal/storage"
+	"cit/internal/utils"
+)
+
+// switchTree 将工作目录从当前快照切换到目标快照。
+// 非强制模式下，如果本地未提交的修改会被覆盖则拒绝切换。
+func (r *Repository) switchTree(currentFiles, targetFiles map[string]*storage.TreeEntry, force bool) error {
+	if !force {
+		if blocked, err := r.checkoutBlockers(currentFiles, targetFiles); err != nil {
+			return err
+		} else if len(blocked) > 0 {
+			return fmt.Errorf("以下文件的本地修改将被覆盖:\n  %s\n请先提交更改，或使用 --force 丢弃本地修改",
+				strings.Join(blocked, "\n  "))
+		}
+	}
+
+	// 先删除目标快照中不存在的文件，文件 d 与目录 d/ 互换时才能创建新的文件或目录
+	for filePath := range currentFiles {
+		if _, ok := targetFiles[filePath]; ok {
+			continue
+		}
+		if err := r.removeWorkingFile(filePath); err != nil {
+			return err
+		}
+	}
+
+	// 写入目标快照中新增或变化的文件
+	for filePath, target := range targetFiles {
+		current, tracked := currentFiles[filePath]
+		if !force && tracked && current.Hash == target.Hash && current.Mode == target.Mode {
+			continue
+		}
+		if err := r.writeWorkingFile(filePath, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkoutBlockers 找出切换快照时会丢失本地修改的文件
+func (r *Repository) checkoutBlockers(currentFiles, targetFiles map[string]*storage.TreeEntry) ([]string, error) {
+	staging, err := r.Storage.GetStaging()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked []string
+	check := func(filePath string) {
+		current, tracked := currentFiles[filePath]
+		target, inTarget := targetFiles[filePath]
+		if tracked && inTarget && current.Hash == target.Hash {
+			// 两个快照中内容相同，本地修改可以保留
+			return
+		}
+
+		if _, staged := staging[filepath.FromSlash(filePath)]; staged {
+			blocked = append(blocked, filePath)
+			return
+		}
+
+		hash, exists := r.workingFileHash(filePath)
+		switch {
+		case tracked && (!exists || hash != current.Hash):
+			// 已跟踪文件被修改或删除
+			blocked = append(blocked, filePath)
+		case !tracked && exists && hash != target.Hash:
+			// 未跟踪文件会被目标快照覆盖
+			blocked = append(blocked, filePath)
+		}
+	}
+
+	for filePath := range targetFiles {
+		check(filePath)
+	}
+	for filePath := range currentFiles {
+		if _, ok := targetFiles[filePath]; !ok {
+			check(filePath)
+		}
+	}
+
+	sort.Strings(blocked)
+	return blocked, nil
+}
+
+// workingFileHash 计算工作目录中文件的哈希，文件不存在时返回false
+func (r *Repository) workingFileHash(relPath string) (string, bool) {
+	fullPath := filepath.Join(r.Path, filepath.FromSlash(relPath))
+	if !utils.IsFile(fullPath) {
+		return "", false
+	}
+
+	hash, err := utils.CalculateFileHash(fullPath)
+	if err != nil {
+		return "", false
+	}
+	return hash, true
+}
+
+// writeWorkingFile 将对象内容写入工作目录
+func (r *Repository) writeWorkingFile(relPath string, entry *storage.TreeEntry) error {
+	content, err := r.Storage.GetObject(entry.Hash)
+	if err != nil {
+		return err
+	}
+	return r.writeWorkingContent(relPath, content, entry.Mode)
+}
+
+// writeWorkingContent 将内容按条目模式写入工作目录中的文件
+func (r *Repository) writeWorkingContent(relPath string, content []byte, mode string) error {
+	fullPath, err := r.workingPath(relPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	perm := os.FileMode(0644)
+	if mode == storage.ModeExecutable {
+		perm = 0755
+	}
+	if err := os.WriteFile(fullPath, content, perm); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %v", relPath, err)
+	}
+	return os.Chmod(fullPath, perm)
+}
+
+// removeWorkingFile 删除工作目录中的文件，并清理变空的父目录
+func (r *Repository) removeWorkingFile(relPath string) error {
+	fullPath, err := r.workingPath(relPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件 %s 失败: %v", relPath, err)
+	}
+
+	for dir := filepath.Dir(fullPath); dir != r.Path && strings.HasPrefix(dir, r.Path); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// workingPath 返回文件在工作目录中的完整路径，拒绝会落到工作目录之外或元数据目录中的路径
+func (r *Repository) workingPath(relPath string) (string, error) {
+	if err := checkTreePath(filepath.ToSlash(relPath)); err != nil {
+		return "", err
+	}
+
+	fullPath := filepath.Join(r.Path, filepath.FromSlash(relPath))
+	rel, err := filepath.Rel(r.Path, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("路径 %s 位于工作目录之外", relPath)
+	}
+	return fullPath, nil
+}
+
+// checkTreePath 检查以"/"分隔的仓库内路径：不能是绝对路径，
+// 每一级名称都要符合树条目名称的规则，也不能是仓库的元数据目录
+func checkTreePath(treePath string) error {
+	for _, name := range strings.Split(treePath, "/") {
+		if err := storage.CheckEntryName(name); err != nil {
+			return fmt.Errorf("无效的路径 %q: %v", treePath, err)
+		}
+		if name == CitDirName {
+			return fmt.Errorf("无效的路径 %q: 不能写入仓库元数据目录", treePath)
+		}
+	}
+	return nil
+}
+
+// OrphanedCommits 返回分离的 HEAD 上既不属于任何分支或标签、也不是 target 祖先的提交，
+// 按提交时间从新到旧排序。切换到 target 后这些提交将无法再通过分支找到。
+func (r *Repository) OrphanedCommits(target string) ([]*storage.Commit, error) {
+	if !r.IsDetached() || r.DetachedHead == "" {
+		return nil, nil
+	}
+
+	branches, err := r.Storage.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := r.ListTags("")
+	if err != nil {
+		return nil, err
+	}
+
+	heads := []string{target}
+	for _, branch := range branches {
+		heads = append(heads, branch.Head)
+	}
+	for _, tag := range tags {
+		heads = append(heads, tag.Commit)
+	}
+
+	reachable := make(map[string]bool)
+	for _, head := range heads {
+		if head == "" || reachable[head] {
+			continue
+		}
+		ancestors, err := r.ancestors(head)
+		if err != nil {
+			return nil, err
+		}
+		for id := range ancestors {
+			reachable[id] = true
+		}
+	}
+
+	return r.walkCommits([]string{r.DetachedHead}, reachable, LogOptions{})
+}
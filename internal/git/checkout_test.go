@@ -0,0 +1,76 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwitchBetweenFileAndDirectory(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := InitRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一个提交中 d 是文件
+	file := filepath.Join(dir, "d")
+	if err := os.WriteFile(file, []byte("file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddToStaging(file); err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.Commit("d 是文件")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二个提交中 d 是目录
+	if _, err := repo.RemovePaths([]string{file}, RemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(dir, "d", "x")
+	if err := os.MkdirAll(filepath.Dir(nested), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nested, []byte("nested\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddToStaging(nested); err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.Commit("d 是目录")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(path, content string) {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Fatalf("%s 的内容为 %q，期望 %q", path, data, content)
+		}
+	}
+
+	// 目录 -> 文件
+	if _, err := repo.CheckoutCommit(first.ID, false); err != nil {
+		t.Fatalf("从目录切换到文件失败: %v", err)
+	}
+	expect(file, "file\n")
+
+	// 文件 -> 目录
+	if _, err := repo.CheckoutCommit(second.ID, false); err != nil {
+		t.Fatalf("从文件切换到目录失败: %v", err)
+	}
+	expect(nested, "nested\n")
+
+	// reset --hard 使用同样的切换逻辑
+	if _, err := repo.Reset(first.ID, ResetHard); err != nil {
+		t.Fatalf("reset --hard 从目录切换到文件失败: %v", err)
+	}
+	expect(file, "file\n")
+}
@@ -0,0 +1,238 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cit/internal/storage"
+)
+
+// 文件变更类型
+const (
+	ChangeAdded    = "A"
+	ChangeModified = "M"
+	ChangeDeleted  = "D"
+)
+
+// DiffOptions 指定差异比较的两侧
+type DiffOptions struct {
+	Staged    bool     // 比较暂存区与HEAD（或指定的修订）
+	Revisions []string // 零到两个修订
+}
+
+// FileChange 表示一个文件在两个快照之间的变化
+type FileChange struct {
+	Path       string
+	Type       string
+	OldMode    string
+	NewMode    string
+	OldHash    string
+	NewHash    string
+	OldContent []byte
+	NewContent []byte
+}
+
+// Diff 按选项比较两个快照，返回按路径排序的文件变化：
+//
+//	无修订            暂存区 -> 工作目录
+//	--staged [修订]    修订（默认HEAD） -> 暂存区
+//	修订              修订 -> 工作目录
+//	修订 修订          修订 -> 修订
+//	A..B              A -> B
+//	A...B             A 与 B 的合并基础 -> B
+func (r *Repository) Diff(opts DiffOptions) ([]*FileChange, error) {
+	if len(opts.Revisions) == 1 && !opts.Staged {
+		rng, err := r.ParseRange(opts.Revisions[0])
+		if err != nil {
+			return nil, err
+		}
+		if rng != nil {
+			from := rng.From
+			if rng.Symmetric {
+				if from, err = r.MergeBase(rng.From, rng.To); err != nil {
+					return nil, err
+				}
+				if from == "" {
+					return nil, fmt.Errorf("%s 没有共同祖先", opts.Revisions[0])
+				}
+			}
+			opts.Revisions = []string{from, rng.To}
+		}
+	}
+
+	if len(opts.Revisions) > 2 || (opts.Staged && len(opts.Revisions) > 1) {
+		return nil, fmt.Errorf("修订参数过多")
+	}
+
+	if len(opts.Revisions) == 2 {
+		oldFiles, err := r.revisionFiles(opts.Revisions[0])
+		if err != nil {
+			return nil, err
+		}
+		newFiles, err := r.revisionFiles(opts.Revisions[1])
+		if err != nil {
+			return nil, err
+		}
+		return r.diffSnapshots(oldFiles, newFiles, false)
+	}
+
+	indexFiles, err := r.indexFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var oldFiles map[string]*storage.TreeEntry
+	if len(opts.Revisions) == 1 {
+		if oldFiles, err = r.revisionFiles(opts.Revisions[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Staged {
+		if oldFiles == nil {
+			if oldFiles, err = r.headFiles(); err != nil {
+				return nil, err
+			}
+		}
+		return r.diffSnapshots(oldFiles, indexFiles, false)
+	}
+
+	if oldFiles == nil {
+		oldFiles = indexFiles
+	}
+
+	// 工作目录一侧只考虑已跟踪的文件
+	tracked := make(map[string]bool, len(indexFiles)+len(oldFiles))
+	for filePath := range indexFiles {
+		tracked[filePath] = true
+	}
+	for filePath := range oldFiles {
+		tracked[filePath] = true
+	}
+	return r.diffSnapshots(oldFiles, r.worktreeFiles(tracked), true)
+}
+
+// diffSnapshots 比较两个快照并读取变化文件的内容，newFromWorkdir 表示新快照的内容来自工作目录
+func (r *Repository) diffSnapshots(oldFiles, newFiles map[string]*storage.TreeEntry, newFromWorkdir bool) ([]*FileChange, error) {
+	paths := make(map[string]bool, len(oldFiles)+len(newFiles))
+	for filePath := range oldFiles {
+		paths[filePath] = true
+	}
+	for filePath := range newFiles {
+		paths[filePath] = true
+	}
+
+	var changes []*FileChange
+	for filePath := range paths {
+		oldEntry, inOld := oldFiles[filePath]
+		newEntry, inNew := newFiles[filePath]
+
+		change := &FileChange{Path: filePath}
+		switch {
+		case inOld && inNew:
+			if oldEntry.Hash == newEntry.Hash && oldEntry.Mode == newEntry.Mode {
+				continue
+			}
+			change.Type = ChangeModified
+		case inNew:
+			change.Type = ChangeAdded
+		default:
+			change.Type = ChangeDeleted
+		}
+
+		if inOld {
+			content, err := r.Storage.GetObject(oldEntry.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("读取 %s 失败: %v", filePath, err)
+			}
+			change.OldMode, change.OldHash, change.OldContent = oldEntry.Mode, oldEntry.Hash, content
+		}
+
+		if inNew {
+			var content []byte
+			var err error
+			if newFromWorkdir {
+				content, err = os.ReadFile(filepath.Join(r.Path, filepath.FromSlash(filePath)))
+			} else {
+				content, err = r.Storage.GetObject(newEntry.Hash)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("读取 %s 失败: %v", filePath, err)
+			}
+			change.NewMode, change.NewHash, change.NewContent = newEntry.Mode, newEntry.Hash, content
+		}
+
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// headFiles 返回 HEAD 指向的提交的快照
+func (r *Repository) headFiles() (map[string]*storage.TreeEntry, error) {
+	head, err := r.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	return r.commitTreeFiles(head)
+}
+
+// revisionFiles 返回指定修订的快照
+func (r *Repository) revisionFiles(rev string) (map[string]*storage.TreeEntry, error) {
+	id, err := r.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+	return r.commitTreeFiles(id)
+}
+
+// indexFiles 返回 HEAD 快照叠加暂存区后的快照
+func (r *Repository) indexFiles() (map[string]*storage.TreeEntry, error) {
+	headFiles, err := r.headFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := r.Storage.GetStaging()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*storage.TreeEntry)
+	for filePath, hash := range stagedSnapshot(headFiles, staging) {
+		if entry, ok := headFiles[filePath]; ok && entry.Hash == hash {
+			files[filePath] = entry
+			continue
+		}
+		files[filePath] = &storage.TreeEntry{
+			Mode: r.fileMode(filePath),
+			Type: storage.TypeBlob,
+			Hash: hash,
+			Name: filePath,
+		}
+	}
+	return files, nil
+}
+
+// worktreeFiles 返回工作目录中指定文件的快照，已删除的文件不包含在内
+func (r *Repository) worktreeFiles(paths map[string]bool) map[string]*storage.TreeEntry {
+	files := make(map[string]*storage.TreeEntry, len(paths))
+	for filePath := range paths {
+		hash, ok := r.workingFileHash(filePath)
+		if !ok {
+			continue
+		}
+		files[filePath] = &storage.TreeEntry{
+			Mode: r.fileMode(filePath),
+			Type: storage.TypeBlob,
+			Hash: hash,
+			Name: filePath,
+		}
+	}
+	return files
+}
@@ -0,0 +1,262 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cit/internal/storage"
+)
+
+// exportTip 是导出时的一个起点：分支或标签引用及其指向的提交
+type exportTip struct {
+	ref    string
+	commit string
+	tag    *storage.Tag // 附注标签对象，分支和轻量标签为 nil
+}
+
+// FastExport 以 git fast-import 流的格式输出所有分支和标签的历史，
+// 可以用 `git fast-import` 在Git仓库中重建。提交按父提交优先的顺序输出，
+// 每个文件对象只输出一次，之后用标记引用。返回因不指向提交而跳过的标签。
+func (r *Repository) FastExport(w io.Writer) ([]string, error) {
+	tips, skipped, err := r.exportTips()
+	if err != nil {
+		return nil, err
+	}
+
+	commits, order, refOf, err := r.exportOrder(tips)
+	if err != nil {
+		return nil, err
+	}
+
+	out := bufio.NewWriter(w)
+	marks := make(map[string]int)
+	nextMark := func(hash string) int {
+		marks[hash] = len(marks) + 1
+		return marks[hash]
+	}
+
+	for _, id := range order {
+		commit := commits[id]
+		files, err := r.listTreeFiles(commit.TreeHash)
+		if err != nil {
+			return nil, err
+		}
+		parentFiles, err := r.commitTreeFiles(commit.FirstParent())
+		if err != nil {
+			return nil, err
+		}
+
+		var deleted, changed []string
+		for filePath := range parentFiles {
+			if _, ok := files[filePath]; !ok {
+				deleted = append(deleted, filePath)
+			}
+		}
+		for filePath, entry := range files {
+			old, ok := parentFiles[filePath]
+			if ok && old.Hash == entry.Hash && old.Mode == entry.Mode {
+				continue
+			}
+			changed = append(changed, filePath)
+			if _, ok := marks[entry.Hash]; ok || entry.Mode == storage.ModeSubmodule {
+				continue
+			}
+			data, err := r.Storage.GetObject(entry.Hash)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(out, "blob\nmark :%d\n", nextMark(entry.Hash))
+			writeFastImportData(out, data)
+		}
+		sort.Strings(deleted)
+		sort.Strings(changed)
+
+		ref := refOf[id]
+		if len(commit.Parents) == 0 {
+			// 没有 from 的提交会接在引用当前的位置之后，根提交需要先清空引用
+			fmt.Fprintf(out, "reset %s\n", ref)
+		}
+		fmt.Fprintf(out, "commit %s\nmark :%d\n", ref, nextMark(id))
+		committer := commit.Committer
+		if committer == "" {
+			committer = commit.Author
+		}
+		fmt.Fprintf(out, "author %s %s\n", fastImportIdent(commit.Author), storage.FormatSignatureTime(commit.Timestamp))
+		fmt.Fprintf(out, "committer %s %s\n", fastImportIdent(committer), storage.FormatSignatureTime(commit.CommitTime()))
+		writeFastImportData(out, []byte(withTrailingNewline(commit.Message)))
+		for i, parent := range commit.Parents {
+			command := "merge"
+			if i == 0 {
+				command = "from"
+			}
+			fmt.Fprintf(out, "%s :%d\n", command, marks[parent])
+		}
+		for _, filePath := range deleted {
+			fmt.Fprintf(out, "D %s\n", quoteFastImportPath(filePath))
+		}
+		for _, filePath := range changed {
+			entry := files[filePath]
+			dataRef := entry.Hash
+			if mark, ok := marks[entry.Hash]; ok {
+				dataRef = fmt.Sprintf(":%d", mark)
+			}
+			fmt.Fprintf(out, "M %s %s %s\n", entry.Mode, dataRef, quoteFastImportPath(filePath))
+		}
+		out.WriteString("\n")
+	}
+
+	for _, tip := range tips {
+		switch {
+		case tip.tag != nil:
+			fmt.Fprintf(out, "tag %s\nfrom :%d\n", strings.TrimPrefix(tip.ref, "refs/tags/"), marks[tip.commit])
+			if tip.tag.Tagger != "" {
+				fmt.Fprintf(out, "tagger %s %s\n", fastImportIdent(tip.tag.Tagger), storage.FormatSignatureTime(tip.tag.Timestamp))
+			}
+			writeFastImportData(out, []byte(withTrailingNewline(tip.tag.Message)))
+		default:
+			fmt.Fprintf(out, "reset %s\nfrom :%d\n\n", tip.ref, marks[tip.commit])
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return nil, fmt.Errorf("写入导出流失败: %v", err)
+	}
+	return skipped, nil
+}
+
+// exportTips 收集所有分支和标签，分支在前，各自按名称排序。
+// fast-import 流中的标签只能指向提交，指向其他对象的标签会被跳过。
+func (r *Repository) exportTips() ([]*exportTip, []string, error) {
+	branches, err := r.Storage.ListBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].Name < branches[j].Name
+	})
+
+	var tips []*exportTip
+	for _, branch := range branches {
+		if branch.Head != "" {
+			tips = append(tips, &exportTip{ref: storage.BranchRef(branch.Name), commit: branch.Head})
+		}
+	}
+
+	tags, err := r.Storage.ListTags()
+	if err != nil {
+		return nil, nil, err
+	}
+	var skipped []string
+	for _, ref := range tags {
+		tip := &exportTip{ref: "refs/tags/" + ref.Name, commit: ref.Target}
+		objType, err := r.Storage.ObjectType(ref.Target)
+		if err != nil {
+			return nil, nil, err
+		}
+		if objType == storage.TypeTag {
+			if tip.tag, err = r.Storage.GetTag(ref.Target); err != nil {
+				return nil, nil, err
+			}
+			tip.commit, objType = tip.tag.Object, tip.tag.ObjectType
+		}
+		if objType != storage.TypeCommit {
+			skipped = append(skipped, ref.Name)
+			continue
+		}
+		tips = append(tips, tip)
+	}
+	return tips, skipped, nil
+}
+
+// exportOrder 从各个起点遍历历史，返回按父提交优先排列的提交ID，
+// 以及每个提交在流中所属的引用（最先到达它的起点）。历史可能很长，因此用显式的栈遍历。
+func (r *Repository) exportOrder(tips []*exportTip) (map[string]*storage.Commit, []string, map[string]string, error) {
+	commits := make(map[string]*storage.Commit)
+	refOf := make(map[string]string)
+	done := make(map[string]bool)
+	var order []string
+
+	for _, tip := range tips {
+		stack := []string{tip.commit}
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			if done[id] {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			if _, visited := commits[id]; !visited {
+				commit, err := r.Storage.GetCommit(id)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				commits[id] = commit
+				refOf[id] = tip.ref
+				// 逆序入栈，使第一个父提交最先输出
+				for i := len(commit.Parents) - 1; i >= 0; i-- {
+					if !done[commit.Parents[i]] {
+						stack = append(stack, commit.Parents[i])
+					}
+				}
+				continue
+			}
+
+			stack = stack[:len(stack)-1]
+			done[id] = true
+			order = append(order, id)
+		}
+	}
+	return commits, order, refOf, nil
+}
+
+// writeFastImportData 输出 data 命令及其内容
+func writeFastImportData(w *bufio.Writer, data []byte) {
+	fmt.Fprintf(w, "data %d\n", len(data))
+	w.Write(data)
+	w.WriteString("\n")
+}
+
+// fastImportIdent 将身份转换为 fast-import 要求的 "姓名 <邮箱>" 格式，旧版本只记录了姓名的身份邮箱为空
+func fastImportIdent(ident string) string {
+	if strings.Contains(ident, "<") && strings.HasSuffix(ident, ">") {
+		return ident
+	}
+	if ident == "" {
+		return "<>"
+	}
+	return ident + " <>"
+}
+
+// withTrailingNewline 补全末尾的换行，与提交和标签对象中保存的说明一致
+func withTrailingNewline(message string) string {
+	if strings.HasSuffix(message, "\n") {
+		return message
+	}
+	return message + "\n"
+}
+
+// quoteFastImportPath 路径以双引号开头或包含换行时按C语言风格加引号，其余路径原样输出
+func quoteFastImportPath(filePath string) string {
+	if !strings.HasPrefix(filePath, `"`) && !strings.Contains(filePath, "\n") {
+		return filePath
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(filePath); i++ {
+		switch c := filePath[i]; c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
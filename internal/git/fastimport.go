@@ -0,0 +1,634 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"cit/internal/storage"
+)
+
+// fastImporter 解析 git fast-import 流并写入本仓库
+type fastImporter struct {
+	repo    *Repository
+	in      *bufio.Reader
+	pending *string           // 读出后退回的一行
+	marks   map[string]string // ":<编号>" -> 对象哈希
+	refs    map[string]string // 流中更新过的引用 -> 对象哈希
+	written int
+
+	// 上一个提交的文件，连续的提交通常以它为父提交，避免每次重新展开树
+	lastCommit string
+	lastFiles  map[string]*storage.TreeEntry
+}
+
+// FastImport 读取 git fast-import 流（例如 `git fast-export --all` 的输出），
+// 创建其中的文件、提交和标签，并写入 refs/heads 和 refs/tags 下的引用，其他引用被忽略。
+// 已存在且指向不同对象的引用会被跳过；当前分支还没有提交时，导入后检出该分支。
+func (r *Repository) FastImport(in io.Reader) (*ImportResult, error) {
+	fi := &fastImporter{
+		repo:  r,
+		in:    bufio.NewReader(in),
+		marks: make(map[string]string),
+		refs:  make(map[string]string),
+	}
+	if err := fi.run(); err != nil {
+		return nil, err
+	}
+
+	branches := make(map[string]string)
+	tags := make(map[string]string)
+	for ref, id := range fi.refs {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			branches[name] = id
+		} else if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+			tags[name] = id
+		}
+	}
+	if len(branches) == 0 && len(tags) == 0 {
+		return nil, fmt.Errorf("导入流中没有分支或标签")
+	}
+
+	result, err := r.importRefs(branches, tags, r.CurrentBranch, "fast-import")
+	if err != nil {
+		return nil, err
+	}
+	result.Objects = fi.written
+	return result, nil
+}
+
+// run 逐条执行流中的命令
+func (fi *fastImporter) run() error {
+	for {
+		line, err := fi.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		command, arg, _ := strings.Cut(line, " ")
+		switch command {
+		case "blob":
+			err = fi.blob()
+		case "commit":
+			err = fi.commit(arg)
+		case "tag":
+			err = fi.tag(arg)
+		case "reset":
+			err = fi.reset(arg)
+		case "done":
+			return nil
+		case "", "checkpoint", "progress", "feature", "option":
+			// 与仓库内容无关的命令
+		default:
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			return fmt.Errorf("不支持的 fast-import 命令: %q", line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// blob 处理 blob 命令：[mark] [original-oid] data
+func (fi *fastImporter) blob() error {
+	mark, err := fi.optional("mark")
+	if err != nil {
+		return err
+	}
+	if _, err := fi.optional("original-oid"); err != nil {
+		return err
+	}
+	data, err := fi.data()
+	if err != nil {
+		return err
+	}
+
+	hash, err := fi.repo.Storage.StoreBlob(data)
+	if err != nil {
+		return err
+	}
+	fi.written++
+	if mark != "" {
+		fi.marks[mark] = hash
+	}
+	return nil
+}
+
+// commit 处理 commit 命令：[mark] [original-oid] [author] committer [encoding] data [from] merge* 文件修改*
+func (fi *fastImporter) commit(ref string) error {
+	mark, err := fi.optional("mark")
+	if err != nil {
+		return err
+	}
+	if _, err := fi.optional("original-oid"); err != nil {
+		return err
+	}
+
+	commit := &storage.Commit{}
+	if author, err := fi.optional("author"); err != nil {
+		return err
+	} else if author != "" {
+		if commit.Author, commit.Timestamp, err = storage.ParseSignature(author); err != nil {
+			return err
+		}
+	}
+	committer, err := fi.optional("committer")
+	if err != nil {
+		return err
+	}
+	if committer == "" {
+		return fmt.Errorf("提交 %s 缺少 committer", ref)
+	}
+	if commit.Committer, commit.CommitTimestamp, err = storage.ParseSignature(committer); err != nil {
+		return err
+	}
+	if commit.Author == "" {
+		commit.Author, commit.Timestamp = commit.Committer, commit.CommitTimestamp
+	}
+	if _, err := fi.optional("encoding"); err != nil {
+		return err
+	}
+	message, err := fi.data()
+	if err != nil {
+		return err
+	}
+	commit.Message = strings.TrimSuffix(string(message), "\n")
+
+	// 省略 from 时接在引用当前的位置之后
+	from, err := fi.optional("from")
+	if err != nil {
+		return err
+	}
+	parent := fi.refs[ref]
+	if from != "" {
+		if parent, err = fi.resolveCommit(from); err != nil {
+			return err
+		}
+	}
+	if parent != "" {
+		commit.Parents = append(commit.Parents, parent)
+	}
+	for {
+		merge, err := fi.optional("merge")
+		if err != nil {
+			return err
+		}
+		if merge == "" {
+			break
+		}
+		id, err := fi.resolveCommit(merge)
+		if err != nil {
+			return err
+		}
+		commit.Parents = append(commit.Parents, id)
+	}
+
+	files, err := fi.parentFiles(parent)
+	if err != nil {
+		return err
+	}
+	if err := fi.fileChanges(files); err != nil {
+		return fmt.Errorf("提交 %s: %v", ref, err)
+	}
+
+	if commit.TreeHash, err = fi.repo.writeTree(files); err != nil {
+		return err
+	}
+	if err := fi.repo.Storage.StoreCommit(commit); err != nil {
+		return err
+	}
+	fi.written++
+	fi.refs[ref] = commit.ID
+	fi.lastCommit, fi.lastFiles = commit.ID, files
+	if mark != "" {
+		fi.marks[mark] = commit.ID
+	}
+	return nil
+}
+
+// parentFiles 返回父提交快照中文件的副本，作为新提交修改的基础
+func (fi *fastImporter) parentFiles(parent string) (map[string]*storage.TreeEntry, error) {
+	if parent == "" || parent != fi.lastCommit {
+		return fi.repo.commitTreeFiles(parent)
+	}
+	files := make(map[string]*storage.TreeEntry, len(fi.lastFiles))
+	for filePath, entry := range fi.lastFiles {
+		files[filePath] = entry
+	}
+	return files, nil
+}
+
+// fileChanges 读取提交的文件修改命令（M、D、C、R、deleteall）并应用到文件快照，遇到其他行时结束
+func (fi *fastImporter) fileChanges(files map[string]*storage.TreeEntry) error {
+	for {
+		line, err := fi.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "":
+			return nil
+		case line == "deleteall":
+			for filePath := range files {
+				delete(files, filePath)
+			}
+		case strings.HasPrefix(line, "M "):
+			if err := fi.modify(files, line[2:]); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "D "):
+			filePath, err := unquoteFastImportPath(line[2:])
+			if err != nil {
+				return err
+			}
+			if err := checkTreePath(filePath); err != nil {
+				return err
+			}
+			removeFastImportPath(files, filePath)
+		case strings.HasPrefix(line, "C "), strings.HasPrefix(line, "R "):
+			src, dst, err := splitFastImportPaths(line[2:])
+			if err != nil {
+				return err
+			}
+			copyFastImportPath(files, src, dst)
+			if line[0] == 'R' {
+				removeFastImportPath(files, src)
+			}
+		default:
+			fi.unread(line)
+			return nil
+		}
+	}
+}
+
+// modify 处理 "M <模式> <数据引用> <路径>"，数据引用可以是标记、对象哈希或 inline
+func (fi *fastImporter) modify(files map[string]*storage.TreeEntry, args string) error {
+	fields := strings.SplitN(args, " ", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("无效的文件修改: M %s", args)
+	}
+	filePath, err := unquoteFastImportPath(fields[2])
+	if err != nil {
+		return err
+	}
+	if err := checkTreePath(filePath); err != nil {
+		return err
+	}
+
+	entry := &storage.TreeEntry{Type: storage.TypeBlob, Name: filePath}
+	switch fields[0] {
+	case "644", storage.ModeFile:
+		entry.Mode = storage.ModeFile
+	case "755", storage.ModeExecutable:
+		entry.Mode = storage.ModeExecutable
+	case storage.ModeSymlink:
+		entry.Mode = storage.ModeSymlink
+	case storage.ModeSubmodule:
+		entry.Mode, entry.Type = storage.ModeSubmodule, storage.TypeCommit
+	default:
+		return fmt.Errorf("不支持的文件模式 %s: %s", fields[0], filePath)
+	}
+
+	switch dataRef := fields[1]; {
+	case dataRef == "inline":
+		data, err := fi.data()
+		if err != nil {
+			return err
+		}
+		if entry.Hash, err = fi.repo.Storage.StoreBlob(data); err != nil {
+			return err
+		}
+		fi.written++
+	case strings.HasPrefix(dataRef, ":"):
+		hash, ok := fi.marks[dataRef]
+		if !ok {
+			return fmt.Errorf("未定义的标记 %s", dataRef)
+		}
+		entry.Hash = hash
+	case isObjectID(dataRef):
+		entry.Hash = dataRef
+	default:
+		return fmt.Errorf("无效的数据引用: %s", dataRef)
+	}
+
+	removeFastImportPath(files, filePath)
+	files[filePath] = entry
+	return nil
+}
+
+// tag 处理 tag 命令：[mark] from [original-oid] [tagger] data
+func (fi *fastImporter) tag(name string) error {
+	mark, err := fi.optional("mark")
+	if err != nil {
+		return err
+	}
+	from, err := fi.optional("from")
+	if err != nil {
+		return err
+	}
+	if from == "" {
+		return fmt.Errorf("标签 %s 缺少 from", name)
+	}
+	if _, err := fi.optional("original-oid"); err != nil {
+		return err
+	}
+
+	tag := &storage.Tag{Name: name, Tagger: getCurrentUser(), Timestamp: time.Unix(time.Now().Unix(), 0)}
+	if tagger, err := fi.optional("tagger"); err != nil {
+		return err
+	} else if tagger != "" {
+		if tag.Tagger, tag.Timestamp, err = storage.ParseSignature(tagger); err != nil {
+			return err
+		}
+	}
+	message, err := fi.data()
+	if err != nil {
+		return err
+	}
+	tag.Message = strings.TrimSuffix(string(message), "\n")
+
+	if tag.Object, err = fi.resolve(from); err != nil {
+		return err
+	}
+	if tag.ObjectType, err = fi.repo.Storage.ObjectType(tag.Object); err != nil {
+		return err
+	}
+	if err := fi.repo.Storage.StoreTag(tag); err != nil {
+		return err
+	}
+	fi.written++
+	fi.refs["refs/tags/"+name] = tag.ID
+	if mark != "" {
+		fi.marks[mark] = tag.ID
+	}
+	return nil
+}
+
+// reset 处理 reset 命令：带 from 时将引用指向该提交，否则清空引用，下一个提交成为根提交
+func (fi *fastImporter) reset(ref string) error {
+	from, err := fi.optional("from")
+	if err != nil {
+		return err
+	}
+	if from == "" {
+		delete(fi.refs, ref)
+		return nil
+	}
+
+	id, err := fi.resolve(from)
+	if err != nil {
+		return err
+	}
+	fi.refs[ref] = id
+	return nil
+}
+
+// resolveCommit 解析 from 和 merge 中的提交
+func (fi *fastImporter) resolveCommit(commitish string) (string, error) {
+	id, err := fi.resolve(commitish)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fi.repo.Storage.GetCommit(id); err != nil {
+		return "", fmt.Errorf("%s 不是提交", commitish)
+	}
+	return id, nil
+}
+
+// resolve 解析标记、流中的引用、对象哈希或本仓库的修订
+func (fi *fastImporter) resolve(name string) (string, error) {
+	if strings.HasPrefix(name, ":") {
+		id, ok := fi.marks[name]
+		if !ok {
+			return "", fmt.Errorf("未定义的标记 %s", name)
+		}
+		return id, nil
+	}
+	if id, ok := fi.refs[name]; ok {
+		return id, nil
+	}
+	if isObjectID(name) {
+		return name, nil
+	}
+	return fi.repo.ResolveRevision(strings.TrimPrefix(name, "refs/heads/"))
+}
+
+// optional 读取以 keyword 开头的可选行，返回其参数；下一行不是该命令时退回并返回空字符串
+func (fi *fastImporter) optional(keyword string) (string, error) {
+	line, err := fi.readLine()
+	if err == io.EOF {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if value, ok := strings.CutPrefix(line, keyword+" "); ok {
+		return value, nil
+	}
+	fi.unread(line)
+	return "", nil
+}
+
+// data 读取 "data <长度>" 或 "data <<<分隔符>" 形式的数据
+func (fi *fastImporter) data() ([]byte, error) {
+	line, err := fi.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("缺少 data 命令: %v", err)
+	}
+	arg, ok := strings.CutPrefix(line, "data ")
+	if !ok {
+		return nil, fmt.Errorf("需要 data 命令，实际为: %q", line)
+	}
+
+	if delim, ok := strings.CutPrefix(arg, "<<"); ok {
+		var buf strings.Builder
+		for {
+			line, err := fi.readLine()
+			if err != nil {
+				return nil, fmt.Errorf("数据缺少结束标记 %s", delim)
+			}
+			if line == delim {
+				return []byte(buf.String()), nil
+			}
+			buf.WriteString(line)
+			buf.WriteString("\n")
+		}
+	}
+
+	size, err := strconv.Atoi(arg)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("无效的数据长度: %q", arg)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(fi.in, data); err != nil {
+		return nil, fmt.Errorf("读取数据失败: %v", err)
+	}
+	// 数据后可以有一个可选的换行
+	if next, err := fi.in.Peek(1); err == nil && next[0] == '\n' {
+		fi.in.Discard(1)
+	}
+	return data, nil
+}
+
+// readLine 读取下一行（不含换行符），优先返回退回的行
+func (fi *fastImporter) readLine() (string, error) {
+	if fi.pending != nil {
+		line := *fi.pending
+		fi.pending = nil
+		return line, nil
+	}
+
+	line, err := fi.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+func (fi *fastImporter) unread(line string) {
+	fi.pending = &line
+}
+
+// removeFastImportPath 删除文件或目录下的所有文件
+func removeFastImportPath(files map[string]*storage.TreeEntry, target string) {
+	delete(files, target)
+	prefix := target + "/"
+	for filePath := range files {
+		if strings.HasPrefix(filePath, prefix) {
+			delete(files, filePath)
+		}
+	}
+}
+
+// copyFastImportPath 复制文件或目录下的所有文件
+func copyFastImportPath(files map[string]*storage.TreeEntry, src, dst string) {
+	copied := make(map[string]*storage.TreeEntry)
+	for filePath, entry := range files {
+		if filePath == src {
+			copied[dst] = entry
+		} else if rest, ok := strings.CutPrefix(filePath, src+"/"); ok {
+			copied[dst+"/"+rest] = entry
+		}
+	}
+
+	removeFastImportPath(files, dst)
+	for filePath, entry := range copied {
+		files[filePath] = entry
+	}
+}
+
+// splitFastImportPaths 拆分 C 和 R 命令的源路径和目标路径，含空格的源路径必须加引号
+func splitFastImportPaths(args string) (string, string, error) {
+	var src, rest string
+	if strings.HasPrefix(args, `"`) {
+		end := closingQuote(args)
+		if end < 0 || end+1 >= len(args) || args[end+1] != ' ' {
+			return "", "", fmt.Errorf("无效的路径: %s", args)
+		}
+		src, rest = args[:end+1], args[end+2:]
+	} else {
+		var ok bool
+		if src, rest, ok = strings.Cut(args, " "); !ok {
+			return "", "", fmt.Errorf("缺少目标路径: %s", args)
+		}
+	}
+
+	src, err := unquoteFastImportPath(src)
+	if err != nil {
+		return "", "", err
+	}
+	dst, err := unquoteFastImportPath(rest)
+	if err != nil {
+		return "", "", err
+	}
+	for _, filePath := range []string{src, dst} {
+		if err := checkTreePath(filePath); err != nil {
+			return "", "", err
+		}
+	}
+	return src, dst, nil
+}
+
+// closingQuote 返回与开头的双引号匹配的结束引号位置，没有时返回 -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquoteFastImportPath 还原C语言风格加引号的路径，没有引号的路径原样返回
+func unquoteFastImportPath(quoted string) (string, error) {
+	if !strings.HasPrefix(quoted, `"`) {
+		return quoted, nil
+	}
+	if closingQuote(quoted) != len(quoted)-1 {
+		return "", fmt.Errorf("无效的路径: %s", quoted)
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(quoted)-1; i++ {
+		c := quoted[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch c = quoted[i]; c {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0', '1', '2', '3':
+			// 三位八进制表示的字节，Git用它转义非ASCII字符
+			if i+2 >= len(quoted) {
+				return "", fmt.Errorf("无效的路径: %s", quoted)
+			}
+			n, err := strconv.ParseUint(quoted[i:i+3], 8, 8)
+			if err != nil {
+				return "", fmt.Errorf("无效的路径: %s", quoted)
+			}
+			b.WriteByte(byte(n))
+			i += 2
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// isObjectID 判断是否为完整的40位对象哈希
+func isObjectID(s string) bool {
+	return len(s) == 40 && hexPattern.MatchString(s)
+}
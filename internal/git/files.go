@@ -0,0 +1,321 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cit/internal/storage"
+	"cit/internal/utils"
+)
+
+// RemoveOptions 控制 RemovePaths 的行为
+type RemoveOptions struct {
+	Cached    bool // 只从暂存区移除，保留工作目录中的文件
+	Force     bool // 忽略本地修改和暂存修改的检查
+	Recursive bool // 允许删除目录
+}
+
+// RemovePaths 从暂存区（以及工作目录）删除已跟踪的文件，paths 为绝对路径。
+// 删除会记录在暂存区中，下次提交时生效。返回被删除的文件。
+func (r *Repository) RemovePaths(paths []string, opts RemoveOptions) ([]string, error) {
+	headFiles, err := r.headFiles()
+	if err != nil {
+		return nil, err
+	}
+	indexFiles, err := r.indexFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]bool, len(indexFiles))
+	for filePath := range indexFiles {
+		candidates[filePath] = true
+	}
+	if !opts.Recursive {
+		for _, p := range paths {
+			relPath, err := r.relativePath(p)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := indexFiles[relPath]; !ok && utils.IsDirectory(p) {
+				return nil, fmt.Errorf("不递归删除目录 '%s'，请使用 -r", relPath)
+			}
+		}
+	}
+
+	matched, err := r.matchPaths(paths, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先检查所有文件，避免删除到一半才失败
+	if !opts.Force {
+		var blocked []string
+		for _, filePath := range matched {
+			index := indexFiles[filePath]
+			head, inHead := headFiles[filePath]
+			hash, exists := r.workingFileHash(filePath)
+
+			stagedChange := !inHead || head.Hash != index.Hash
+			localChange := exists && hash != index.Hash
+			switch {
+			case opts.Cached && stagedChange && localChange:
+				blocked = append(blocked, filePath+"（暂存的内容与HEAD和工作目录都不同）")
+			case !opts.Cached && stagedChange:
+				blocked = append(blocked, filePath+"（有已暂存的修改）")
+			case !opts.Cached && localChange:
+				blocked = append(blocked, filePath+"（有本地修改）")
+			}
+		}
+		if len(blocked) > 0 {
+			return nil, fmt.Errorf("以下文件的修改将会丢失:\n  %s\n使用 --cached 保留文件，或使用 -f 强制删除",
+				strings.Join(blocked, "\n  "))
+		}
+	}
+
+	for _, filePath := range matched {
+		if err := r.unstagePath(filePath, headFiles); err != nil {
+			return nil, err
+		}
+		if !opts.Cached {
+			if err := r.removeWorkingFile(filePath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return matched, nil
+}
+
+// MovePath 移动或重命名已跟踪的文件或目录，src 和 dst 为绝对路径。
+// dst 是已存在的目录时移动到该目录下。工作目录中的文件随之移动，
+// 暂存区中记录为旧路径的删除和新路径的添加。返回移动后的相对路径。
+func (r *Repository) MovePath(src, dst string) (string, error) {
+	srcRel, err := r.relativePath(src)
+	if err != nil {
+		return "", err
+	}
+	if utils.IsDirectory(dst) {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	dstRel, err := r.relativePath(dst)
+	if err != nil {
+		return "", err
+	}
+
+	if srcRel == "." || dstRel == "." {
+		return "", fmt.Errorf("不能移动仓库根目录")
+	}
+	if dstRel == srcRel || strings.HasPrefix(dstRel, srcRel+"/") {
+		return "", fmt.Errorf("不能将 '%s' 移动到自身内部", srcRel)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("目标 '%s' 已存在", dstRel)
+	}
+	if _, err := os.Lstat(src); err != nil {
+		return "", fmt.Errorf("源路径 '%s' 不存在", srcRel)
+	}
+
+	headFiles, err := r.headFiles()
+	if err != nil {
+		return "", err
+	}
+	indexFiles, err := r.indexFiles()
+	if err != nil {
+		return "", err
+	}
+	conflicts, err := r.Storage.GetConflicts()
+	if err != nil {
+		return "", err
+	}
+
+	// 收集要移动的已跟踪文件，目录中的未跟踪文件随目录移动但不加入暂存区
+	moves := make(map[string]string)
+	for filePath := range indexFiles {
+		if filePath == srcRel {
+			moves[filePath] = dstRel
+		} else if rest, ok := strings.CutPrefix(filePath, srcRel+"/"); ok {
+			moves[filePath] = path.Join(dstRel, rest)
+		}
+	}
+	if len(moves) == 0 {
+		return "", fmt.Errorf("'%s' 没有被跟踪", srcRel)
+	}
+	for filePath := range moves {
+		if _, ok := conflicts[filepath.FromSlash(filePath)]; ok {
+			return "", fmt.Errorf("'%s' 有未解决的冲突", filePath)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", fmt.Errorf("创建目录失败: %v", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return "", fmt.Errorf("移动 '%s' 失败: %v", srcRel, err)
+	}
+
+	for oldPath, newPath := range moves {
+		if err := r.unstagePath(oldPath, headFiles); err != nil {
+			return "", err
+		}
+		if err := r.Storage.AddToStaging(filepath.FromSlash(newPath), indexFiles[oldPath].Hash); err != nil {
+			return "", err
+		}
+	}
+
+	return dstRel, nil
+}
+
+// RestoreOptions 控制 RestorePaths 恢复的位置和来源
+type RestoreOptions struct {
+	Source   string // 恢复来源的修订，为空时工作目录从暂存区恢复、暂存区从 HEAD 恢复
+	Staged   bool   // 恢复暂存区
+	Worktree bool   // 恢复工作目录
+}
+
+// RestorePaths 将路径（绝对路径）在暂存区和/或工作目录中的内容恢复为来源中的版本。
+// 来源中不存在的已跟踪文件会被删除。返回被恢复的文件。
+func (r *Repository) RestorePaths(paths []string, opts RestoreOptions) ([]string, error) {
+	if !opts.Staged && !opts.Worktree {
+		opts.Worktree = true
+	}
+
+	var restored []string
+	if opts.Staged {
+		var err error
+		if restored, err = r.ResetPaths(opts.Source, paths); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.Worktree {
+		return restored, nil
+	}
+
+	// 只恢复工作目录时以暂存区为来源
+	var sourceFiles map[string]*storage.TreeEntry
+	var err error
+	switch {
+	case opts.Source != "":
+		sourceFiles, err = r.revisionFiles(opts.Source)
+	case opts.Staged:
+		sourceFiles, err = r.headFiles()
+	default:
+		sourceFiles, err = r.indexFiles()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	indexFiles, err := r.indexFiles()
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := r.Storage.GetConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]bool, len(sourceFiles)+len(indexFiles))
+	for filePath := range sourceFiles {
+		candidates[filePath] = true
+	}
+	for filePath := range indexFiles {
+		candidates[filePath] = true
+	}
+
+	matched, err := r.matchPaths(paths, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]bool)
+	for _, filePath := range restored {
+		changed[filePath] = true
+	}
+	for _, filePath := range matched {
+		if _, ok := conflicts[filepath.FromSlash(filePath)]; ok && opts.Source == "" {
+			return nil, fmt.Errorf("'%s' 有未解决的冲突，请使用 cit resolve 或指定 --source", filePath)
+		}
+
+		hash, exists := r.workingFileHash(filePath)
+		if source, ok := sourceFiles[filePath]; ok {
+			if exists && hash == source.Hash {
+				continue
+			}
+			err = r.writeWorkingFile(filePath, source)
+		} else {
+			if !exists {
+				continue
+			}
+			err = r.removeWorkingFile(filePath)
+		}
+		if err != nil {
+			return nil, err
+		}
+		changed[filePath] = true
+	}
+
+	restored = restored[:0]
+	for filePath := range changed {
+		restored = append(restored, filePath)
+	}
+	sort.Strings(restored)
+	return restored, nil
+}
+
+// unstagePath 在暂存区中记录文件删除：HEAD中存在的文件暂存删除，
+// 只存在于暂存区的新文件直接移除暂存条目
+func (r *Repository) unstagePath(filePath string, headFiles map[string]*storage.TreeEntry) error {
+	osPath := filepath.FromSlash(filePath)
+	if _, ok := headFiles[filePath]; ok {
+		return r.Storage.AddToStaging(osPath, "")
+	}
+	return r.Storage.RemoveFromStaging(osPath)
+}
+
+// matchPaths 返回候选文件中与路径（文件或目录，绝对路径）匹配的文件，
+// 任何一个路径没有匹配的文件时返回错误
+func (r *Repository) matchPaths(paths []string, candidates map[string]bool) ([]string, error) {
+	matched := make(map[string]bool)
+	for _, p := range paths {
+		relPath, err := r.relativePath(p)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for filePath := range candidates {
+			if relPath == "." || filePath == relPath || strings.HasPrefix(filePath, relPath+"/") {
+				matched[filePath] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("路径 '%s' 不匹配任何已跟踪的文件", relPath)
+		}
+	}
+
+	files := make([]string, 0, len(matched))
+	for filePath := range matched {
+		files = append(files, filePath)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// relativePath 将绝对路径转换为相对仓库根目录、以"/"分隔的路径
+func (r *Repository) relativePath(absPath string) (string, error) {
+	relPath, err := filepath.Rel(r.Path, absPath)
+	if err != nil {
+		return "", fmt.Errorf("获取相对路径失败: %v", err)
+	}
+	relPath = filepath.ToSlash(relPath)
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return "", fmt.Errorf("路径 '%s' 不在仓库中", absPath)
+	}
+	return relPath, nil
+}
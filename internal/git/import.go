@@ -0,0 +1,318 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cit/internal/storage"
+	"cit/internal/utils"
+)
+
+// ImportResult 汇总一次导入的结果
+type ImportResult struct {
+	Objects  int      // 导入的对象数
+	Branches []string // 导入的分支
+	Tags     []string // 导入的标签
+	Skipped  []string // 本仓库中已存在且指向不同对象而跳过的引用
+	Head     string   // 导入后检出的分支，没有检出时为空
+}
+
+// ImportGit 从本地Git仓库（.git 目录或包含它的工作目录）导入所有分支和标签，
+// 读取松散对象和包文件，转换为本仓库格式的提交、树、文件和标签对象，保留作者和时间。
+// 当前分支还没有提交时，检出源仓库 HEAD 所在的分支。
+func (r *Repository) ImportGit(path string) (*ImportResult, error) {
+	gitDir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Base(gitDir) != GitDirName {
+		if candidate := filepath.Join(gitDir, GitDirName); utils.IsDirectory(candidate) {
+			gitDir = candidate
+		}
+	}
+	if gitDir == r.gitDir {
+		return nil, fmt.Errorf("不能从仓库自身导入")
+	}
+
+	src, err := storage.OpenGitDir(gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	branches, tags, err := gitRefs(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(branches) == 0 && len(tags) == 0 {
+		return nil, fmt.Errorf("%s 中没有分支或标签", gitDir)
+	}
+
+	imp := &importer{src: src, dst: r.Storage, converted: make(map[string]string)}
+	for _, refs := range []map[string]string{branches, tags} {
+		for name, id := range refs {
+			newID, err := imp.convert(id)
+			if err != nil {
+				return nil, fmt.Errorf("导入 %s 失败: %v", name, err)
+			}
+			refs[name] = newID
+		}
+	}
+	srcBranch, _, _ := src.ReadHead()
+	result, err := r.importRefs(branches, tags, srcBranch, "import: from "+gitDir)
+	if err != nil {
+		return nil, err
+	}
+	result.Objects = imp.written
+	return result, nil
+}
+
+// importRefs 将导入的分支和标签写入本仓库，已存在且指向不同对象的引用会被跳过。
+// 当前分支还没有提交且 headBranch 在导入的分支中时，检出该分支：先更新工作目录再写入引用，
+// 工作目录中的未跟踪文件会被覆盖时不写入任何引用。
+func (r *Repository) importRefs(branches, tags map[string]string, headBranch, reason string) (*ImportResult, error) {
+	result := &ImportResult{}
+
+	head, _ := r.HeadCommit()
+	if _, ok := branches[headBranch]; ok && head == "" && !r.IsDetached() {
+		if existing, err := r.Storage.GetBranchHead(headBranch); err != nil || existing == "" {
+			files, err := r.commitTreeFiles(branches[headBranch])
+			if err != nil {
+				return nil, err
+			}
+			if err := r.switchTree(map[string]*storage.TreeEntry{}, files, false); err != nil {
+				return nil, err
+			}
+			result.Head = headBranch
+		}
+	}
+
+	for _, name := range sortedKeys(branches) {
+		id := branches[name]
+		existing, err := r.Storage.GetBranchHead(name)
+		switch {
+		case err == nil && existing == id:
+			continue
+		case err == nil && existing != "":
+			result.Skipped = append(result.Skipped, storage.BranchRef(name))
+			continue
+		case err == nil:
+			err = r.Storage.UpdateBranchHead(name, id)
+		default:
+			err = r.Storage.CreateBranch(&storage.Branch{Name: name, Head: id})
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Storage.AppendReflog(storage.BranchRef(name), r.reflogEntry("", id, reason)); err != nil {
+			return nil, err
+		}
+		result.Branches = append(result.Branches, name)
+	}
+
+	for _, name := range sortedKeys(tags) {
+		id := tags[name]
+		if existing, err := r.Storage.GetTagRef(name); err == nil {
+			if existing != id {
+				result.Skipped = append(result.Skipped, "refs/tags/"+name)
+			}
+			continue
+		}
+		if err := r.Storage.CreateTagRef(name, id); err != nil {
+			return nil, err
+		}
+		result.Tags = append(result.Tags, name)
+	}
+
+	if result.Head != "" {
+		if err := r.Storage.SetHeadBranch(result.Head); err != nil {
+			return nil, err
+		}
+		r.CurrentBranch = result.Head
+		if err := r.logRefUpdate("", branches[result.Head], reason, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// gitRefs 读取源仓库的分支和标签，包括 packed-refs 中的引用，松散引用优先
+func gitRefs(src *storage.Storage) (map[string]string, map[string]string, error) {
+	branches := make(map[string]string)
+	tags := make(map[string]string)
+
+	packed, err := src.PackedRefs()
+	if err != nil {
+		return nil, nil, err
+	}
+	for ref, id := range packed {
+		if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			branches[name] = id
+		} else if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+			tags[name] = id
+		}
+	}
+
+	loose, err := src.ListBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, branch := range loose {
+		if branch.Head != "" {
+			branches[branch.Name] = branch.Head
+		}
+	}
+
+	looseTags, err := src.ListTags()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tag := range looseTags {
+		tags[tag.Name] = tag.Target
+	}
+	return branches, tags, nil
+}
+
+// importer 将源仓库的对象转换后写入本仓库，记录 源哈希 -> 新哈希。
+// 本仓库是Git格式时对象原样复制，哈希不变；否则树、提交和标签按本仓库的格式重新编码。
+type importer struct {
+	src, dst  *storage.Storage
+	converted map[string]string
+	written   int
+}
+
+// convert 导入对象及其引用的所有对象，返回对象在本仓库中的哈希
+func (imp *importer) convert(hash string) (string, error) {
+	if newHash, ok := imp.converted[hash]; ok {
+		return newHash, nil
+	}
+
+	objType, data, err := imp.src.ReadObject(hash)
+	if err != nil {
+		return "", fmt.Errorf("读取对象 %s 失败: %v", hash, err)
+	}
+
+	switch objType {
+	case storage.TypeCommit:
+		return imp.convertCommits(hash)
+	case storage.TypeTree:
+		return imp.convertTree(hash, data)
+	case storage.TypeTag:
+		return imp.convertTag(hash, data)
+	}
+	return imp.write(hash, objType, data, nil)
+}
+
+// convertCommits 导入提交及其全部祖先。历史可能很长，因此用显式的栈按父提交优先的顺序处理。
+func (imp *importer) convertCommits(hash string) (string, error) {
+	stack := []string{hash}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		if _, ok := imp.converted[id]; ok {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		commit, err := imp.src.GetCommit(id)
+		if err != nil {
+			return "", err
+		}
+
+		pending := false
+		for _, parent := range commit.Parents {
+			if _, ok := imp.converted[parent]; !ok {
+				stack = append(stack, parent)
+				pending = true
+			}
+		}
+		if pending {
+			continue
+		}
+		stack = stack[:len(stack)-1]
+
+		if commit.TreeHash, err = imp.convert(commit.TreeHash); err != nil {
+			return "", err
+		}
+		for i, parent := range commit.Parents {
+			commit.Parents[i] = imp.converted[parent]
+		}
+
+		// 原样复制时才需要原始内容
+		var data []byte
+		if imp.dst.Format() == storage.FormatGit {
+			if _, data, err = imp.src.ReadObject(id); err != nil {
+				return "", err
+			}
+		}
+		if _, err := imp.write(id, storage.TypeCommit, data, func() (string, error) {
+			err := imp.dst.StoreCommit(commit)
+			return commit.ID, err
+		}); err != nil {
+			return "", err
+		}
+	}
+	return imp.converted[hash], nil
+}
+
+func (imp *importer) convertTree(hash string, data []byte) (string, error) {
+	tree, err := imp.src.GetTree(hash)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range tree.Entries {
+		// 子模块指向其他仓库中的提交，不需要导入
+		if entry.Mode == storage.ModeSubmodule {
+			continue
+		}
+		if entry.Hash, err = imp.convert(entry.Hash); err != nil {
+			return "", err
+		}
+	}
+	return imp.write(hash, storage.TypeTree, data, func() (string, error) {
+		return imp.dst.StoreTree(tree)
+	})
+}
+
+func (imp *importer) convertTag(hash string, data []byte) (string, error) {
+	tag, err := imp.src.GetTag(hash)
+	if err != nil {
+		return "", err
+	}
+	if tag.Object, err = imp.convert(tag.Object); err != nil {
+		return "", err
+	}
+	return imp.write(hash, storage.TypeTag, data, func() (string, error) {
+		err := imp.dst.StoreTag(tag)
+		return tag.ID, err
+	})
+}
+
+// write 写入转换后的对象。本仓库是Git格式或者对象不需要重新编码（encode 为 nil）时原样写入。
+func (imp *importer) write(hash, objType string, data []byte, encode func() (string, error)) (string, error) {
+	var newHash string
+	var err error
+	if encode == nil || imp.dst.Format() == storage.FormatGit {
+		newHash, err = imp.dst.WriteObject(objType, data)
+	} else {
+		newHash, err = encode()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	imp.converted[hash] = newHash
+	imp.written++
+	return newHash, nil
+}
+
+// sortedKeys 返回按名称排序的键
+func sortedKeys(refs map[string]string) []string {
+	keys := make([]string, 0, len(refs))
+	for key := range refs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package git
+
+import (
+	"container/heap"
+
+	"cit/internal/storage"
+)
+
+// LogOptions 控制提交历史的遍历方式
+type LogOptions struct {
+	All         bool   // 从所有分支头和标签开始遍历
+	Start       string // 起始修订或 A..B、A...B 范围，为空时从当前分支开始
+	MaxCount    int    // 最多返回的提交数，0 表示不限制
+	FirstParent bool   // 只沿第一个父提交遍历
+}
+
+// Log 从起始提交出发沿父提交链遍历历史，按提交时间从新到旧返回
+func (r *Repository) Log(opts LogOptions) ([]*storage.Commit, error) {
+	starts, hidden, err := r.logStarts(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.walkCommits(starts, hidden, opts)
+}
+
+// walkCommits 从起点出发按提交时间遍历历史，跳过 hidden 中的提交
+func (r *Repository) walkCommits(starts []string, hidden map[string]bool, opts LogOptions) ([]*storage.Commit, error) {
+	queue := &commitQueue{}
+	seen := make(map[string]bool)
+	push := func(id string) error {
+		if id == "" || seen[id] || hidden[id] {
+			return nil
+		}
+		seen[id] = true
+		commit, err := r.Storage.GetCommit(id)
+		if err != nil {
+			return err
+		}
+		heap.Push(queue, commit)
+		return nil
+	}
+
+	for _, id := range starts {
+		if err := push(id); err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []*storage.Commit
+	for queue.Len() > 0 {
+		if opts.MaxCount > 0 && len(commits) >= opts.MaxCount {
+			break
+		}
+
+		commit := heap.Pop(queue).(*storage.Commit)
+		commits = append(commits, commit)
+
+		parents := commit.Parents
+		if opts.FirstParent && len(parents) > 1 {
+			parents = parents[:1]
+		}
+		for _, parent := range parents {
+			if err := push(parent); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return commits, nil
+}
+
+// logStarts 确定历史遍历的起点，以及范围表达式排除的提交
+func (r *Repository) logStarts(opts LogOptions) ([]string, map[string]bool, error) {
+	if opts.All {
+		branches, err := r.Storage.ListBranches()
+		if err != nil {
+			return nil, nil, err
+		}
+		starts := make([]string, 0, len(branches))
+		for _, branch := range branches {
+			starts = append(starts, branch.Head)
+		}
+
+		tags, err := r.ListTags("")
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, tag := range tags {
+			starts = append(starts, tag.Commit)
+		}
+		return starts, nil, nil
+	}
+
+	if opts.Start == "" {
+		head, err := r.HeadCommit()
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{head}, nil, nil
+	}
+
+	rng, err := r.ParseRange(opts.Start)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rng == nil {
+		id, err := r.ResolveRevision(opts.Start)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{id}, nil, nil
+	}
+
+	// A..B 排除 A 可达的提交
+	hidden, err := r.ancestors(rng.From)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rng.Symmetric {
+		return []string{rng.To}, hidden, nil
+	}
+
+	// A...B 只排除两侧共同可达的提交
+	toAncestors, err := r.ancestors(rng.To)
+	if err != nil {
+		return nil, nil, err
+	}
+	for id := range hidden {
+		if !toAncestors[id] {
+			delete(hidden, id)
+		}
+	}
+	return []string{rng.From, rng.To}, hidden, nil
+}
+
+// commitQueue 按提交时间排序的优先队列，最新的提交先出队
+type commitQueue []*storage.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].CommitTime().After(q[j].CommitTime())
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) { *q = append(*q, x.(*storage.Commit)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
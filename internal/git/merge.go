@@ -0,0 +1,650 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cit/internal/diff"
+	"cit/internal/storage"
+)
+
+// MergeOptions 控制合并行为
+type MergeOptions struct {
+	NoFF    bool   // 即使可以快进也创建合并提交
+	Message string // 合并提交信息，为空时使用默认信息
+}
+
+// MergeResult 表示合并结果
+type MergeResult struct {
+	Success     bool     `json:"success"`
+	FastForward bool     `json:"fast_forward"`
+	UpToDate    bool     `json:"up_to_date"`
+	CommitID    string   `json:"commit_id"`
+	MergeBase   string   `json:"merge_base"`
+	Conflicts   []string `json:"conflicts"`
+	MergedFiles []string `json:"merged_files"`
+	Message     string   `json:"message"`
+}
+
+// MergeBranch 将指定分支（或任意修订）合并到当前分支
+func (r *Repository) MergeBranch(sourceBranch string, opts MergeOptions) (*MergeResult, error) {
+	// 检查是否已有进行中的合并
+	if state, err := r.Storage.GetMergeState(); err != nil {
+		return nil, err
+	} else if state != nil {
+		return nil, fmt.Errorf("合并进行中，请先完成合并（cit merge --continue）或放弃合并（cit merge --abort）")
+	}
+
+	// 解析要合并的修订
+	sourceHead, err := r.ResolveRevision(sourceBranch)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析 '%s': %v", sourceBranch, err)
+	}
+
+	// 获取当前分支头
+	currentHead, err := r.HeadCommit()
+	if err != nil {
+		return nil, fmt.Errorf("获取当前分支头失败: %v", err)
+	}
+
+	// 检查是否有未提交的更改
+	if err := r.ensureCleanWorktree(); err != nil {
+		return nil, err
+	}
+
+	if opts.Message == "" {
+		if _, err := r.Storage.GetBranchHead(sourceBranch); err == nil {
+			opts.Message = fmt.Sprintf("Merge branch '%s' into %s", sourceBranch, r.headName())
+		} else {
+			opts.Message = fmt.Sprintf("Merge commit '%s' into %s", sourceBranch, r.headName())
+		}
+	}
+
+	return r.performMerge(sourceBranch, sourceHead, currentHead, opts)
+}
+
+// performMerge 执行实际的合并操作
+func (r *Repository) performMerge(sourceBranch, sourceHead, currentHead string, opts MergeOptions) (*MergeResult, error) {
+	result := &MergeResult{
+		Conflicts:   []string{},
+		MergedFiles: []string{},
+	}
+
+	var base string
+	if currentHead != "" {
+		var err error
+		if base, err = r.MergeBase(currentHead, sourceHead); err != nil {
+			return nil, fmt.Errorf("计算合并基础失败: %v", err)
+		}
+	}
+	result.MergeBase = base
+
+	// 源分支已经包含在当前分支中
+	if base == sourceHead {
+		result.Success = true
+		result.UpToDate = true
+		result.CommitID = currentHead
+		result.Message = "已经是最新的"
+		return result, nil
+	}
+
+	// 当前分支是源分支的祖先，可以快进
+	if base == currentHead && !opts.NoFF {
+		if err := r.fastForward(currentHead, sourceHead, fmt.Sprintf("merge %s: Fast-forward", sourceBranch)); err != nil {
+			return nil, fmt.Errorf("快进失败: %v", err)
+		}
+		result.Success = true
+		result.FastForward = true
+		result.CommitID = sourceHead
+		result.Message = fmt.Sprintf("快进合并分支 '%s' 到 '%s'", sourceBranch, r.headName())
+		return result, nil
+	}
+
+	baseFiles, err := r.commitTreeFiles(base)
+	if err != nil {
+		return nil, err
+	}
+	oursFiles, err := r.commitTreeFiles(currentHead)
+	if err != nil {
+		return nil, err
+	}
+	theirsFiles, err := r.commitTreeFiles(sourceHead)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := diff.Labels{Ours: r.headName(), Base: "base", Theirs: sourceBranch}
+	merged, conflicts, conflictContent, err := r.mergeTrees(baseFiles, oursFiles, theirsFiles, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	// 将自动合并的结果写入工作目录
+	if err := r.switchTree(oursFiles, merged, false); err != nil {
+		return nil, err
+	}
+	for filePath, entry := range merged {
+		if ours, ok := oursFiles[filePath]; !ok || ours.Hash != entry.Hash {
+			result.MergedFiles = append(result.MergedFiles, filePath)
+		}
+	}
+	for filePath := range oursFiles {
+		if _, ok := merged[filePath]; !ok {
+			result.MergedFiles = append(result.MergedFiles, filePath)
+		}
+	}
+	sort.Strings(result.MergedFiles)
+
+	if len(conflicts) > 0 {
+		// 有冲突：暂存已自动合并的文件，冲突文件写入冲突标记
+		for _, filePath := range result.MergedFiles {
+			hash := ""
+			if entry, ok := merged[filePath]; ok {
+				hash = entry.Hash
+			}
+			if err := r.Storage.AddToStaging(filepath.FromSlash(filePath), hash); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := r.writeConflicts(conflictContent, baseFiles, oursFiles, theirsFiles); err != nil {
+			return nil, fmt.Errorf("生成冲突标记失败: %v", err)
+		}
+
+		// 在暂存区中记录冲突文件的三方版本
+		for _, filePath := range conflicts {
+			entry := &storage.ConflictEntry{
+				Base:   entryHash(baseFiles[filePath]),
+				Ours:   entryHash(oursFiles[filePath]),
+				Theirs: entryHash(theirsFiles[filePath]),
+			}
+			if err := r.Storage.SetConflict(filepath.FromSlash(filePath), entry); err != nil {
+				return nil, err
+			}
+		}
+
+		// 记录合并状态，解决冲突后由 commit 或 merge --continue 完成合并
+		state := &storage.MergeState{
+			MergeHead: sourceHead,
+			OrigHead:  currentHead,
+			Message:   opts.Message,
+		}
+		if err := r.Storage.SaveMergeState(state); err != nil {
+			return nil, err
+		}
+
+		result.Conflicts = conflicts
+		result.Message = fmt.Sprintf("合并失败，发现 %d 个冲突", len(conflicts))
+		return result, nil
+	}
+
+	// 无冲突，创建合并提交
+	treeHash, err := r.writeTree(merged)
+	if err != nil {
+		return nil, fmt.Errorf("构建树对象失败: %v", err)
+	}
+
+	parents := []string{sourceHead}
+	if currentHead != "" {
+		parents = []string{currentHead, sourceHead}
+	}
+	commit, err := r.createCommit(treeHash, parents, opts.Message)
+	if err != nil {
+		return nil, fmt.Errorf("创建合并提交失败: %v", err)
+	}
+
+	result.Success = true
+	result.CommitID = commit.ID
+	result.Message = fmt.Sprintf("成功合并分支 '%s' 到 '%s'", sourceBranch, r.headName())
+	return result, nil
+}
+
+// GetMergeState 返回进行中的合并，没有时返回 nil
+func (r *Repository) GetMergeState() (*storage.MergeState, error) {
+	return r.Storage.GetMergeState()
+}
+
+// ContinueMerge 在冲突解决后创建合并提交
+func (r *Repository) ContinueMerge() (*storage.Commit, error) {
+	state, err := r.Storage.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("没有进行中的合并")
+	}
+
+	return r.Commit(state.Message)
+}
+
+// AbortMerge 放弃进行中的合并，恢复合并前的分支、暂存区和工作目录
+func (r *Repository) AbortMerge() error {
+	state, err := r.Storage.GetMergeState()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("没有进行中的合并")
+	}
+
+	head, err := r.HeadCommit()
+	if err != nil {
+		return err
+	}
+
+	// 收集合并过程中可能写入工作目录的所有文件
+	touched, err := r.commitTreeFiles(head)
+	if err != nil {
+		return err
+	}
+	theirsFiles, err := r.commitTreeFiles(state.MergeHead)
+	if err != nil {
+		return err
+	}
+	for filePath, entry := range theirsFiles {
+		if _, ok := touched[filePath]; !ok {
+			touched[filePath] = entry
+		}
+	}
+
+	origFiles, err := r.commitTreeFiles(state.OrigHead)
+	if err != nil {
+		return err
+	}
+
+	if err := r.switchTree(touched, origFiles, true); err != nil {
+		return err
+	}
+	if err := r.Storage.ClearStaging(); err != nil {
+		return err
+	}
+	// 有冲突的合并不会移动 HEAD，只有 HEAD 确实变化过时才移回，避免多余的引用日志记录
+	if state.OrigHead != "" && state.OrigHead != head {
+		if err := r.updateHead(state.OrigHead, "merge --abort: moving to "+state.OrigHead); err != nil {
+			return err
+		}
+	}
+	return r.Storage.ClearMergeState()
+}
+
+// fastForward 将当前分支（或分离的 HEAD）直接移动到目标提交
+func (r *Repository) fastForward(currentHead, targetHead, reason string) error {
+	currentFiles, err := r.commitTreeFiles(currentHead)
+	if err != nil {
+		return err
+	}
+	targetFiles, err := r.commitTreeFiles(targetHead)
+	if err != nil {
+		return err
+	}
+
+	if err := r.switchTree(currentFiles, targetFiles, false); err != nil {
+		return err
+	}
+	return r.updateHead(targetHead, reason)
+}
+
+// mergeTrees 对三个快照做三方合并，返回合并结果、冲突文件列表以及冲突文件应写入工作目录的内容。
+// 双方都修改的文本文件按行合并，只有修改区块重叠时才产生冲突；冲突文件在结果中保留我们一方的版本。
+func (r *Repository) mergeTrees(baseFiles, oursFiles, theirsFiles map[string]*storage.TreeEntry, labels diff.Labels) (map[string]*storage.TreeEntry, []string, map[string][]byte, error) {
+	paths := make(map[string]bool)
+	for _, files := range []map[string]*storage.TreeEntry{baseFiles, oursFiles, theirsFiles} {
+		for filePath := range files {
+			paths[filePath] = true
+		}
+	}
+
+	merged := make(map[string]*storage.TreeEntry)
+	conflictContent := make(map[string][]byte)
+	var conflicts []string
+	for filePath := range paths {
+		base, ours, theirs := baseFiles[filePath], oursFiles[filePath], theirsFiles[filePath]
+
+		switch {
+		case sameEntry(ours, theirs):
+			// 两边相同（包括都删除）
+		case sameEntry(base, ours):
+			// 只有对方修改
+			ours = theirs
+		case sameEntry(base, theirs):
+			// 只有我们修改
+		case ours == nil || theirs == nil:
+			// 一方删除而另一方修改：工作目录中保留修改的版本
+			survivor := ours
+			if survivor == nil {
+				survivor = theirs
+			}
+			content, err := r.entryContent(survivor)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			conflicts = append(conflicts, filePath)
+			conflictContent[filePath] = content
+		default:
+			entry, content, err := r.mergeFile(base, ours, theirs, labels)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if entry == nil {
+				conflicts = append(conflicts, filePath)
+				conflictContent[filePath] = content
+			} else {
+				ours = entry
+			}
+		}
+
+		if ours != nil {
+			merged[filePath] = ours
+		}
+	}
+
+	sort.Strings(conflicts)
+	return merged, conflicts, conflictContent, nil
+}
+
+// mergeFile 对双方都修改的文件做按行三方合并。
+// 合并成功时返回新的条目；有冲突时条目为 nil，并返回带冲突标记的内容。
+func (r *Repository) mergeFile(base, ours, theirs *storage.TreeEntry, labels diff.Labels) (*storage.TreeEntry, []byte, error) {
+	baseContent, err := r.entryContent(base)
+	if err != nil {
+		return nil, nil, err
+	}
+	oursContent, err := r.entryContent(ours)
+	if err != nil {
+		return nil, nil, err
+	}
+	theirsContent, err := r.entryContent(theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 二进制文件无法按行合并，工作目录中保留我们的版本
+	if diff.IsBinary(baseContent) || diff.IsBinary(oursContent) || diff.IsBinary(theirsContent) {
+		return nil, oursContent, nil
+	}
+
+	result := diff.Merge3(baseContent, oursContent, theirsContent, labels)
+	if result.Conflicts > 0 {
+		return nil, result.Content, nil
+	}
+
+	hash, err := r.Storage.StoreBlob(result.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &storage.TreeEntry{Mode: mergedMode(base, ours, theirs), Type: storage.TypeBlob, Hash: hash, Name: ours.Name}, nil, nil
+}
+
+// sameEntry 比较两个条目的内容是否相同，nil 表示文件不存在
+func sameEntry(a, b *storage.TreeEntry) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Hash == b.Hash && a.Mode == b.Mode
+}
+
+// writeConflicts 将冲突文件的内容写入工作目录，文件模式按合并后的模式保留
+func (r *Repository) writeConflicts(conflictContent map[string][]byte, baseFiles, oursFiles, theirsFiles map[string]*storage.TreeEntry) error {
+	for filePath, content := range conflictContent {
+		mode := mergedMode(baseFiles[filePath], oursFiles[filePath], theirsFiles[filePath])
+		if err := r.writeWorkingContent(filePath, content, mode); err != nil {
+			return fmt.Errorf("为文件 %s 写入冲突标记失败: %v", filePath, err)
+		}
+	}
+	return nil
+}
+
+// mergedMode 返回合并后文件的模式：只有一方修改了模式时采用修改后的模式，
+// 一方删除时采用另一方的模式
+func mergedMode(base, ours, theirs *storage.TreeEntry) string {
+	switch {
+	case ours == nil:
+		return theirs.Mode
+	case theirs == nil:
+		return ours.Mode
+	case base != nil && ours.Mode == base.Mode:
+		return theirs.Mode
+	}
+	return ours.Mode
+}
+
+// entryHash 返回条目的哈希，nil 条目返回空字符串
+func entryHash(entry *storage.TreeEntry) string {
+	if entry == nil {
+		return ""
+	}
+	return entry.Hash
+}
+
+// entryContent 读取条目对应的对象内容，nil 条目返回空内容
+func (r *Repository) entryContent(entry *storage.TreeEntry) ([]byte, error) {
+	if entry == nil {
+		return nil, nil
+	}
+	return r.Storage.GetObject(entry.Hash)
+}
+
+// ensureCleanWorktree 检查暂存区和已跟踪文件没有未提交的修改
+func (r *Repository) ensureCleanWorktree() error {
+	status, err := r.GetStatus()
+	if err != nil {
+		return err
+	}
+
+	if len(status.StagedNew)+len(status.StagedModified)+len(status.StagedDeleted)+len(status.StagedRenamed) > 0 ||
+		len(status.ModifiedFiles)+len(status.DeletedFiles) > 0 {
+		return fmt.Errorf("当前分支有未提交的更改，请先提交或暂存")
+	}
+	return nil
+}
+
+// IsAncestor 判断 ancestor 是否为 descendant 的祖先（或同一个提交）
+func (r *Repository) IsAncestor(ancestor, descendant string) (bool, error) {
+	ancestors, err := r.ancestors(descendant)
+	if err != nil {
+		return false, err
+	}
+	return ancestors[ancestor], nil
+}
+
+// MergeBase 计算两个提交的合并基础，即提交图中的最近公共祖先。
+// 存在多个最近公共祖先时（交叉合并），选择提交时间最新的一个。
+func (r *Repository) MergeBase(a, b string) (string, error) {
+	ancestorsA, err := r.ancestors(a)
+	if err != nil {
+		return "", err
+	}
+	ancestorsB, err := r.ancestors(b)
+	if err != nil {
+		return "", err
+	}
+
+	var common []string
+	for id := range ancestorsB {
+		if ancestorsA[id] {
+			common = append(common, id)
+		}
+	}
+
+	// 去掉是其他公共祖先的祖先的提交，剩下的就是最近公共祖先
+	redundant := make(map[string]bool)
+	for _, id := range common {
+		if redundant[id] {
+			continue
+		}
+		commit, err := r.Storage.GetCommit(id)
+		if err != nil {
+			return "", err
+		}
+		for _, parent := range commit.Parents {
+			parentAncestors, err := r.ancestors(parent)
+			if err != nil {
+				return "", err
+			}
+			for ancestor := range parentAncestors {
+				redundant[ancestor] = true
+			}
+		}
+	}
+
+	var best *storage.Commit
+	for _, id := range common {
+		if redundant[id] {
+			continue
+		}
+		commit, err := r.Storage.GetCommit(id)
+		if err != nil {
+			return "", err
+		}
+		if best == nil || commit.CommitTime().After(best.CommitTime()) {
+			best = commit
+		}
+	}
+
+	if best == nil {
+		return "", nil
+	}
+	return best.ID, nil
+}
+
+// ancestors 返回提交及其所有祖先的集合
+func (r *Repository) ancestors(id string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	stack := []string{id}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == "" || seen[current] {
+			continue
+		}
+		seen[current] = true
+
+		commit, err := r.Storage.GetCommit(current)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, commit.Parents...)
+	}
+	return seen, nil
+}
+
+// ConflictHunks 返回冲突文件中的所有冲突区块
+func (r *Repository) ConflictHunks(filePath string) ([]*diff.Conflict, error) {
+	fullPath, _, _, err := r.conflictEntry(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取冲突文件失败: %v", err)
+	}
+
+	segments, err := diff.ParseConflicts(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析冲突标记失败: %v", err)
+	}
+	return diff.Conflicts(segments), nil
+}
+
+// ResolveConflict 按策略改写冲突文件的内容，冲突区块之外的内容保持不变。
+// 只提供一个策略时应用到所有冲突区块，否则依次对应每个冲突区块。
+// 冲突记录会保留在暂存区中，直到用户使用 add 重新暂存该文件。
+func (r *Repository) ResolveConflict(filePath string, strategies []string) error {
+	fullPath, relPath, entry, err := r.conflictEntry(filePath)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取冲突文件失败: %v", err)
+	}
+
+	segments, err := diff.ParseConflicts(content)
+	if err != nil {
+		return fmt.Errorf("解析冲突标记失败: %v", err)
+	}
+
+	// 没有冲突标记（修改/删除冲突或二进制文件），只能整体选择一方的版本
+	if len(diff.Conflicts(segments)) == 0 {
+		if len(strategies) != 1 || (strategies[0] != diff.ResolveOurs && strategies[0] != diff.ResolveTheirs) {
+			return fmt.Errorf("文件中没有冲突标记，只能使用 ours 或 theirs 选择整个文件")
+		}
+
+		hash := entry.Ours
+		if strategies[0] == diff.ResolveTheirs {
+			hash = entry.Theirs
+		}
+		if hash == "" {
+			return r.removeWorkingFile(relPath)
+		}
+		return r.writeWorkingFile(relPath, &storage.TreeEntry{Mode: storage.ModeFile, Type: storage.TypeBlob, Hash: hash})
+	}
+
+	resolved, err := diff.ResolveSegments(segments, strategies)
+	if err != nil {
+		return err
+	}
+
+	// 写入解决后的内容
+	if err := os.WriteFile(fullPath, resolved, 0644); err != nil {
+		return fmt.Errorf("写入解决后的内容失败: %v", err)
+	}
+
+	return nil
+}
+
+// conflictEntry 返回冲突文件的绝对路径、仓库内的相对路径和冲突记录
+func (r *Repository) conflictEntry(filePath string) (string, string, *storage.ConflictEntry, error) {
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", "", nil, err
+	}
+	relPath, err := filepath.Rel(r.Path, fullPath)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("获取相对路径失败: %v", err)
+	}
+
+	conflicts, err := r.Storage.GetConflicts()
+	if err != nil {
+		return "", "", nil, err
+	}
+	entry, ok := conflicts[relPath]
+	if !ok {
+		return "", "", nil, fmt.Errorf("文件 '%s' 没有未解决的冲突", filepath.ToSlash(relPath))
+	}
+	return fullPath, filepath.ToSlash(relPath), entry, nil
+}
+
+// GetConflictStatus 获取暂存区中记录的未解决冲突文件
+func (r *Repository) GetConflictStatus() ([]string, error) {
+	entries, err := r.Storage.GetConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]string, 0, len(entries))
+	for filePath := range entries {
+		conflicts = append(conflicts, filepath.ToSlash(filePath))
+	}
+	sort.Strings(conflicts)
+	return conflicts, nil
+}
+
+// GetConflicts 获取冲突文件在三方中的版本，键为"/"分隔的路径
+func (r *Repository) GetConflicts() (map[string]*storage.ConflictEntry, error) {
+	entries, err := r.Storage.GetConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make(map[string]*storage.ConflictEntry, len(entries))
+	for filePath, entry := range entries {
+		conflicts[filepath.ToSlash(filePath)] = entry
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConflictKeepsModeAndAbortLeavesHead(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := InitRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "run.sh")
+	tool := filepath.Join(dir, "tool.sh")
+	commit := func(content string, files ...string) {
+		t.Helper()
+		for _, file := range files {
+			if err := os.WriteFile(file, []byte(content), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.AddToStaging(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := repo.Commit(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit("base\n", script, tool)
+	if err := repo.CreateBranch("other"); err != nil {
+		t.Fatal(err)
+	}
+	// 我们修改 run.sh 并删除 tool.sh，对方修改两者
+	if _, err := repo.RemovePaths([]string{tool}, RemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	commit("ours\n", script)
+	if err := repo.CheckoutBranch("other", false); err != nil {
+		t.Fatal(err)
+	}
+	commit("theirs\n", script, tool)
+	if err := repo.CheckoutBranch("main", false); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _, err := repo.Reflog("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.HeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := repo.MergeBranch("other", MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || len(result.Conflicts) != 2 {
+		t.Fatalf("期望2个冲突文件，实际 %+v", result)
+	}
+	for _, file := range []string{script, tool} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&0100 == 0 {
+			t.Fatalf("冲突文件 %s 丢失了可执行权限: %v", file, info.Mode())
+		}
+	}
+
+	if err := repo.AbortMerge(); err != nil {
+		t.Fatal(err)
+	}
+	after, _, err := repo.Reflog("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("HEAD 没有移动，放弃合并不应写入引用日志: %d -> %d 条", len(before), len(after))
+	}
+	if current, _ := repo.HeadCommit(); current != head {
+		t.Fatalf("放弃合并后 HEAD 应保持为 %s，实际 %s", head, current)
+	}
+	data, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "ours\n" {
+		t.Fatalf("放弃合并后应恢复我们的版本，实际 %q", data)
+	}
+	if _, err := os.Stat(tool); !os.IsNotExist(err) {
+		t.Fatalf("放弃合并后应删除我们一方已删除的文件")
+	}
+}
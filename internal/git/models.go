@@ -3,15 +3,32 @@ package git
 // Status 表示仓库状态
 type Status struct {
 	CurrentBranch  string   `json:"current_branch"`
+	Detached       bool     `json:"detached,omitempty"`
 	LastCommit     string   `json:"last_commit"`
-	StagedFiles   []string `json:"staged_files"`
-	ModifiedFiles []string `json:"modified_files"`
+	MergeHead      string   `json:"merge_head,omitempty"`
+	StagedFiles    []string `json:"staged_files"`
+	StagedNew      []string `json:"staged_new"`
+	StagedModified []string `json:"staged_modified"`
+	StagedDeleted  []string `json:"staged_deleted"`
+	StagedRenamed  []string `json:"staged_renamed"` // "旧路径 -> 新路径"
+	ModifiedFiles  []string `json:"modified_files"`
+	DeletedFiles   []string `json:"deleted_files"`
 	UntrackedFiles []string `json:"untracked_files"`
+	Conflicts      []string `json:"conflicts"`
+}
+
+// IsClean 判断暂存区和工作目录是否都没有变更
+func (s *Status) IsClean() bool {
+	return len(s.StagedNew) == 0 && len(s.StagedModified) == 0 && len(s.StagedDeleted) == 0 &&
+		len(s.StagedRenamed) == 0 &&
+		len(s.ModifiedFiles) == 0 && len(s.DeletedFiles) == 0 && len(s.UntrackedFiles) == 0 &&
+		len(s.Conflicts) == 0
 }
 
 // WorkdirStatus 表示工作目录状态
 type WorkdirStatus struct {
 	ModifiedFiles  []string `json:"modified_files"`
+	DeletedFiles   []string `json:"deleted_files"`
 	UntrackedFiles []string `json:"untracked_files"`
 }
 
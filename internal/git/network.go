@@ -62,8 +62,8 @@ func (r *Repository) PushToGitHub(remoteName, branchName, token string) error {
 		return fmt.Errorf("GitHub连接测试失败: %v", err)
 	}
 
-	// 获取当前分支的提交
-	commits, err := r.Storage.GetCommitHistory()
+	// 获取待推送分支的提交
+	commits, err := r.Log(LogOptions{Start: branchName})
 	if err != nil {
 		return fmt.Errorf("获取提交历史失败: %v", err)
 	}
@@ -0,0 +1,70 @@
+package git
+
+import (
+	"fmt"
+	"time"
+
+	"cit/internal/storage"
+)
+
+// Reflog 返回引用日志（最新的在前）及其完整引用名，name 为空或 HEAD 时返回 HEAD 的日志
+func (r *Repository) Reflog(name string) ([]*storage.ReflogEntry, string, error) {
+	ref := "HEAD"
+	if name != "" && name != "HEAD" {
+		if _, err := r.Storage.GetBranchHead(name); err != nil {
+			return nil, "", err
+		}
+		ref = storage.BranchRef(name)
+	}
+
+	entries, err := r.Storage.ReadReflog(ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("读取引用日志失败: %v", err)
+	}
+	return entries, ref, nil
+}
+
+// resolveReflog 解析 <引用>@{N}，返回引用在倒数第N次变更前指向的提交。
+// 引用为空时使用当前分支，分离状态下使用 HEAD。
+func (r *Repository) resolveReflog(name string, n int) (string, error) {
+	display := name
+	if name == "" {
+		name = r.headName()
+	}
+
+	entries, _, err := r.Reflog(name)
+	if err != nil {
+		return "", err
+	}
+	if n >= len(entries) {
+		return "", fmt.Errorf("'%s@{%d}' 超出范围：引用日志只有 %d 条记录", display, n, len(entries))
+	}
+
+	id := entries[n].New
+	if id == storage.ZeroID {
+		return "", fmt.Errorf("'%s@{%d}' 时引用不存在", display, n)
+	}
+	return id, nil
+}
+
+// logRefUpdate 记录 HEAD 的变化；includeBranch 为 true 时同时记录到当前分支的日志中
+func (r *Repository) logRefUpdate(oldID, newID, reason string, includeBranch bool) error {
+	entry := r.reflogEntry(oldID, newID, reason)
+	if err := r.Storage.AppendReflog("HEAD", entry); err != nil {
+		return err
+	}
+	if includeBranch && !r.IsDetached() {
+		return r.Storage.AppendReflog(storage.BranchRef(r.CurrentBranch), entry)
+	}
+	return nil
+}
+
+func (r *Repository) reflogEntry(oldID, newID, reason string) *storage.ReflogEntry {
+	return &storage.ReflogEntry{
+		Old:       oldID,
+		New:       newID,
+		Identity:  getCurrentUser(),
+		Timestamp: time.Unix(time.Now().Unix(), 0),
+		Message:   reason,
+	}
+}
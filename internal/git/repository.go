@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,22 +14,46 @@ import (
 	"cit/internal/utils"
 )
 
+// CitDirName 仓库元数据目录名
+const CitDirName = ".cit-version01-无法批量提交"
+
+// GitDirName Git兼容格式仓库的元数据目录名
+const GitDirName = ".git"
+
+// AllowGitDir 为 true 时，FindRepository 也会打开没有 cit 标记的 .git 目录（由Git创建的仓库）
+var AllowGitDir bool
+
 // Repository 表示一个Git仓库
 type Repository struct {
-	ID            string    `json:"id"`
-	Path          string    `json:"path"`
-	CreatedAt     time.Time `json:"created_at"`
-	CurrentBranch string    `json:"current_branch"`
-	Storage       *storage.Storage
+	ID            string           `json:"id"`
+	Path          string           `json:"path"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CurrentBranch string           `json:"-"` // 从 HEAD 文件读取，分离状态下为空
+	DetachedHead  string           `json:"-"` // 分离状态下 HEAD 指向的提交ID
+	Storage       *storage.Storage `json:"-"`
+
+	gitDir string // 仓库元数据目录
 }
 
 // InitRepository 初始化一个新的Git仓库
 func InitRepository(path string) (*Repository, error) {
+	return InitRepositoryFormat(path, storage.FormatCit)
+}
+
+// InitRepositoryFormat 以指定格式初始化仓库。FormatGit 格式的仓库元数据保存在 .git 目录中，
+// 对象和引用与Git逐字节兼容，可以直接使用Git工具查看。
+func InitRepositoryFormat(path, format string) (*Repository, error) {
 	// 生成仓库ID
 	repoID := generateRepositoryID(path)
+	if format == storage.FormatGit {
+		repoID = gitRepositoryID(path)
+	}
 
 	// 创建仓库目录结构
-	gitDir := filepath.Join(path, ".cit-version01-无法批量提交")
+	gitDir := filepath.Join(path, CitDirName)
+	if format == storage.FormatGit {
+		gitDir = filepath.Join(path, GitDirName)
+	}
 	if err := os.MkdirAll(gitDir, 0755); err != nil {
 		return nil, fmt.Errorf("创建仓库目录失败: %v", err)
 	}
@@ -43,10 +68,13 @@ func InitRepository(path string) (*Repository, error) {
 	}
 
 	// 初始化存储
-	storage, err := storage.NewStorage(gitDir)
+	storage, err := storage.NewStorage(gitDir, format)
 	if err != nil {
 		return nil, fmt.Errorf("初始化存储失败: %v", err)
 	}
+	if err := storage.MarkCitGitDir(); err != nil {
+		return nil, fmt.Errorf("写入配置失败: %v", err)
+	}
 
 	// 创建仓库对象
 	repo := &Repository{
@@ -55,10 +83,11 @@ func InitRepository(path string) (*Repository, error) {
 		CreatedAt:     time.Now(),
 		CurrentBranch: "main",
 		Storage:       storage,
+		gitDir:        gitDir,
 	}
 
-	// 创建主分支
-	if err := repo.CreateBranch("main"); err != nil {
+	// HEAD 指向主分支，分支在第一次提交时创建
+	if err := storage.SetHeadBranch("main"); err != nil {
 		return nil, fmt.Errorf("创建主分支失败: %v", err)
 	}
 
@@ -77,12 +106,21 @@ func FindRepository(path string) (*Repository, error) {
 		return nil, err
 	}
 
-	// 向上查找.cit目录
+	// 向上查找.cit目录，没有时使用Git兼容格式的.git目录。
+	// 普通的Git仓库只有标记为 cit 仓库或设置了 AllowGitDir 时才会打开，避免误改其中的历史。
 	for {
-		gitDir := filepath.Join(currentPath, ".cit-version01-无法批量提交")
+		gitDir := filepath.Join(currentPath, CitDirName)
 		if _, err := os.Stat(gitDir); err == nil {
 			// 找到仓库，加载信息
-			return loadRepository(gitDir)
+			return loadRepository(gitDir, storage.FormatCit)
+		}
+		gitDir = filepath.Join(currentPath, GitDirName)
+		if utils.IsDirectory(gitDir) {
+			legacy := utils.FileExists(filepath.Join(gitDir, "repository.json"))
+			if !AllowGitDir && !legacy && !storage.IsCitGitDir(gitDir) {
+				return nil, fmt.Errorf("%s 是由Git创建的仓库，使用 --git 参数或运行 git config cit.repository true 后才能用 cit 操作", gitDir)
+			}
+			return loadRepository(gitDir, storage.FormatGit)
 		}
 
 		parent := filepath.Dir(currentPath)
@@ -103,14 +141,14 @@ func (r *Repository) AddToStaging(filePath string) error {
 		return fmt.Errorf("获取相对路径失败: %v", err)
 	}
 
-	// 计算文件哈希
-	hash, err := utils.CalculateFileHash(filePath)
-	if err != nil {
-		return fmt.Errorf("计算文件哈希失败: %v", err)
+	// 文件已被删除时，如果它在HEAD中被跟踪，则暂存删除
+	if !utils.FileExists(filePath) {
+		return r.stageRemoval(relPath)
 	}
 
 	// 存储文件对象
-	if err := r.Storage.StoreObject(hash, filePath); err != nil {
+	hash, err := r.Storage.StoreObject(filePath)
+	if err != nil {
 		return fmt.Errorf("存储文件对象失败: %v", err)
 	}
 
@@ -118,6 +156,31 @@ func (r *Repository) AddToStaging(filePath string) error {
 	return r.Storage.AddToStaging(relPath, hash)
 }
 
+// stageRemoval 在暂存区中记录文件删除
+func (r *Repository) stageRemoval(relPath string) error {
+	var head string
+	if commit, err := r.HeadCommit(); err == nil {
+		head = commit
+	}
+
+	headFiles, err := r.commitTreeFiles(head)
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := r.Storage.GetConflicts()
+	if err != nil {
+		return err
+	}
+
+	_, tracked := headFiles[filepath.ToSlash(relPath)]
+	if _, conflicted := conflicts[relPath]; !tracked && !conflicted {
+		return fmt.Errorf("路径不存在")
+	}
+
+	return r.Storage.AddToStaging(relPath, "")
+}
+
 // Commit 提交暂存区的更改
 func (r *Repository) Commit(message string) (*storage.Commit, error) {
 	// 获取暂存区内容
@@ -126,34 +189,52 @@ func (r *Repository) Commit(message string) (*storage.Commit, error) {
 		return nil, fmt.Errorf("获取暂存区失败: %v", err)
 	}
 
-	if len(staging) == 0 {
+	// 获取进行中的合并
+	mergeState, err := r.Storage.GetMergeState()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(staging) == 0 && mergeState == nil {
 		return nil, fmt.Errorf("暂存区为空")
 	}
 
-	// 获取当前分支的最新提交
-	var parentID string
-	if currentCommit, err := r.Storage.GetBranchHead(r.CurrentBranch); err == nil {
-		parentID = currentCommit
+	if mergeState != nil {
+		conflicts, err := r.GetConflictStatus()
+		if err != nil {
+			return nil, fmt.Errorf("获取冲突状态失败: %v", err)
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("仍有未解决的冲突: %s", strings.Join(conflicts, ", "))
+		}
 	}
 
-	// 创建提交对象
-	commit := &storage.Commit{
-		ID:        utils.GenerateID(),
-		Message:   message,
-		Author:    getCurrentUser(),
-		Timestamp: time.Now(),
-		ParentID:  parentID,
-		TreeHash:  "", // 这里应该计算树对象的哈希
+	// 获取 HEAD 指向的提交
+	var parents []string
+	var parentTree string
+	if currentCommit, err := r.HeadCommit(); err == nil && currentCommit != "" {
+		parent, err := r.Storage.GetCommit(currentCommit)
+		if err != nil {
+			return nil, fmt.Errorf("读取父提交失败: %v", err)
+		}
+		parents = []string{parent.ID}
+		parentTree = parent.TreeHash
 	}
 
-	// 保存提交对象
-	if err := r.Storage.StoreCommit(commit); err != nil {
-		return nil, fmt.Errorf("保存提交失败: %v", err)
+	// 提交结束合并时，记录被合并的提交为第二个父提交
+	if mergeState != nil {
+		parents = append(parents, mergeState.MergeHead)
 	}
 
-	// 更新分支头
-	if err := r.Storage.UpdateBranchHead(r.CurrentBranch, commit.ID); err != nil {
-		return nil, fmt.Errorf("更新分支头失败: %v", err)
+	// 以父提交的树为基础构建新的树对象
+	treeHash, err := r.buildTree(parentTree, staging)
+	if err != nil {
+		return nil, fmt.Errorf("构建树对象失败: %v", err)
+	}
+
+	commit, err := r.createCommit(treeHash, parents, message)
+	if err != nil {
+		return nil, err
 	}
 
 	// 清空暂存区
@@ -161,41 +242,127 @@ func (r *Repository) Commit(message string) (*storage.Commit, error) {
 		return nil, fmt.Errorf("清空暂存区失败: %v", err)
 	}
 
+	if mergeState != nil {
+		if err := r.Storage.ClearMergeState(); err != nil {
+			return nil, err
+		}
+	}
+
+	return commit, nil
+}
+
+// createCommit 创建提交对象并移动 HEAD
+func (r *Repository) createCommit(treeHash string, parents []string, message string) (*storage.Commit, error) {
+	// 时间精确到秒以保证序列化后可以还原
+	now := time.Unix(time.Now().Unix(), 0)
+	user := getCurrentUser()
+	commit := &storage.Commit{
+		Message:         message,
+		Author:          user,
+		Timestamp:       now,
+		Committer:       user,
+		CommitTimestamp: now,
+		Parents:         parents,
+		TreeHash:        treeHash,
+	}
+
+	// 保存提交对象，提交ID由内容哈希决定
+	if err := r.Storage.StoreCommit(commit); err != nil {
+		return nil, fmt.Errorf("保存提交失败: %v", err)
+	}
+
+	// 更新分支头，引用日志记录提交的种类和标题
+	reason := "commit"
+	switch {
+	case len(parents) == 0:
+		reason = "commit (initial)"
+	case len(parents) > 1:
+		reason = "commit (merge)"
+	}
+	if err := r.updateHead(commit.ID, reason+": "+firstLine(message)); err != nil {
+		return nil, fmt.Errorf("更新分支头失败: %v", err)
+	}
+
 	return commit, nil
 }
 
-// GetStatus 获取仓库状态
+// GetStatus 获取仓库状态，比较工作目录、暂存区和HEAD的快照
 func (r *Repository) GetStatus() (*Status, error) {
 	status := &Status{
 		CurrentBranch: r.CurrentBranch,
+		Detached:      r.IsDetached(),
 	}
 
 	// 获取最新提交
-	if commit, err := r.Storage.GetBranchHead(r.CurrentBranch); err == nil {
+	if commit, err := r.HeadCommit(); err == nil {
 		status.LastCommit = commit
 	}
 
-	// 获取暂存区文件
-	if staging, err := r.Storage.GetStaging(); err == nil {
-		status.StagedFiles = make([]string, 0, len(staging))
-		for file := range staging {
-			status.StagedFiles = append(status.StagedFiles, file)
+	// 获取进行中的合并
+	if state, err := r.Storage.GetMergeState(); err == nil && state != nil {
+		status.MergeHead = state.MergeHead
+	}
+
+	headFiles, err := r.commitTreeFiles(status.LastCommit)
+	if err != nil {
+		return nil, fmt.Errorf("读取HEAD快照失败: %v", err)
+	}
+
+	staging, err := r.Storage.GetStaging()
+	if err != nil {
+		return nil, err
+	}
+
+	// 比较暂存区和HEAD
+	status.StagedFiles = make([]string, 0, len(staging))
+	for file, hash := range staging {
+		filePath := filepath.ToSlash(file)
+		status.StagedFiles = append(status.StagedFiles, filePath)
+
+		head, inHead := headFiles[filePath]
+		switch {
+		case hash == "":
+			if inHead {
+				status.StagedDeleted = append(status.StagedDeleted, filePath)
+			}
+		case !inHead:
+			status.StagedNew = append(status.StagedNew, filePath)
+		case head.Hash != hash:
+			status.StagedModified = append(status.StagedModified, filePath)
 		}
 	}
 
-	// 获取工作目录状态
-	workdirStatus, err := r.getWorkdirStatus()
-	if err == nil {
-		status.ModifiedFiles = workdirStatus.ModifiedFiles
-		status.UntrackedFiles = workdirStatus.UntrackedFiles
+	status.StagedRenamed, status.StagedNew, status.StagedDeleted = detectRenames(headFiles, staging, status.StagedNew, status.StagedDeleted)
+
+	// 未解决的冲突
+	conflicts, err := r.GetConflicts()
+	if err != nil {
+		return nil, err
+	}
+	for filePath := range conflicts {
+		status.Conflicts = append(status.Conflicts, filePath)
+	}
+	sort.Strings(status.Conflicts)
+
+	// 比较工作目录和暂存区，冲突文件单独列出
+	workdirStatus, err := r.getWorkdirStatus(headFiles, staging)
+	if err != nil {
+		return nil, err
+	}
+	status.ModifiedFiles = withoutPaths(workdirStatus.ModifiedFiles, conflicts)
+	status.DeletedFiles = withoutPaths(workdirStatus.DeletedFiles, conflicts)
+	status.UntrackedFiles = withoutPaths(workdirStatus.UntrackedFiles, conflicts)
+
+	for _, files := range [][]string{status.StagedFiles, status.StagedNew, status.StagedModified, status.StagedDeleted, status.StagedRenamed} {
+		sort.Strings(files)
 	}
 
 	return status, nil
 }
 
-// GetCommitHistory 获取提交历史
+// GetCommitHistory 获取当前分支的提交历史
 func (r *Repository) GetCommitHistory() ([]*storage.Commit, error) {
-	return r.Storage.GetCommitHistory()
+	return r.Log(LogOptions{})
 }
 
 // ListBranches 列出所有分支
@@ -203,8 +370,13 @@ func (r *Repository) ListBranches() ([]*storage.Branch, error) {
 	return r.Storage.ListBranches()
 }
 
-// CreateBranch 创建新分支
+// CreateBranch 在当前提交上创建新分支
 func (r *Repository) CreateBranch(name string) error {
+	return r.CreateBranchAt(name, "")
+}
+
+// CreateBranchAt 在指定修订上创建新分支，起点为空时使用当前提交
+func (r *Repository) CreateBranchAt(name, startPoint string) error {
 	// 检查分支是否已存在
 	branches, err := r.Storage.ListBranches()
 	if err != nil {
@@ -217,23 +389,37 @@ func (r *Repository) CreateBranch(name string) error {
 		}
 	}
 
-	// 获取当前分支头
+	// 确定分支起点
 	var head string
-	if currentCommit, err := r.Storage.GetBranchHead(r.CurrentBranch); err == nil {
+	if startPoint != "" {
+		if head, err = r.ResolveRevision(startPoint); err != nil {
+			return err
+		}
+	} else if currentCommit, err := r.HeadCommit(); err == nil {
 		head = currentCommit
 	}
+	if head == "" {
+		return fmt.Errorf("当前分支 '%s' 还没有提交，无法创建分支 '%s'", r.headName(), name)
+	}
 
 	// 创建新分支
 	branch := &storage.Branch{
 		Name: name,
 		Head: head,
 	}
+	if err := r.Storage.CreateBranch(branch); err != nil {
+		return err
+	}
 
-	return r.Storage.CreateBranch(branch)
+	if startPoint == "" {
+		startPoint = "HEAD"
+	}
+	return r.Storage.AppendReflog(storage.BranchRef(name), r.reflogEntry("", head, "branch: Created from "+startPoint))
 }
 
-// CheckoutBranch 切换到指定分支
-func (r *Repository) CheckoutBranch(name string) error {
+// CheckoutBranch 切换到指定分支，并将工作目录更新为该分支的快照。
+// force 为 true 时丢弃暂存区和工作目录中的本地修改。
+func (r *Repository) CheckoutBranch(name string, force bool) error {
 	// 检查分支是否存在
 	branches, err := r.Storage.ListBranches()
 	if err != nil {
@@ -252,16 +438,137 @@ func (r *Repository) CheckoutBranch(name string) error {
 		return fmt.Errorf("分支 '%s' 不存在", name)
 	}
 
+	if err := r.checkoutSnapshot(targetBranch.Head, force); err != nil {
+		return err
+	}
+
 	// 更新当前分支
+	oldHead, _ := r.HeadCommit()
+	from := r.headName()
+	if r.IsDetached() {
+		from = r.DetachedHead
+	}
+	if err := r.Storage.SetHeadBranch(name); err != nil {
+		return err
+	}
 	r.CurrentBranch = name
-	return r.save()
+	r.DetachedHead = ""
+	return r.logRefUpdate(oldHead, targetBranch.Head, fmt.Sprintf("checkout: moving from %s to %s", from, name), false)
 }
 
-// GetCurrentBranch 获取当前分支名
+// CheckoutCommit 切换到任意修订，HEAD 进入分离状态，返回切换到的提交ID
+func (r *Repository) CheckoutCommit(rev string, force bool) (string, error) {
+	commitID, err := r.ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.checkoutSnapshot(commitID, force); err != nil {
+		return "", err
+	}
+
+	oldHead, _ := r.HeadCommit()
+	from := r.headName()
+	if r.IsDetached() {
+		from = r.DetachedHead
+	}
+	if err := r.Storage.SetHeadDetached(commitID); err != nil {
+		return "", err
+	}
+	r.CurrentBranch = ""
+	r.DetachedHead = commitID
+	if err := r.logRefUpdate(oldHead, commitID, fmt.Sprintf("checkout: moving from %s to %s", from, rev), false); err != nil {
+		return "", err
+	}
+	return commitID, nil
+}
+
+// checkoutSnapshot 将工作目录从 HEAD 的快照切换到目标提交的快照
+func (r *Repository) checkoutSnapshot(targetHead string, force bool) error {
+	// 合并进行中时不能切换分支
+	if state, err := r.Storage.GetMergeState(); err != nil {
+		return err
+	} else if state != nil && !force {
+		return fmt.Errorf("合并进行中，请先完成合并（cit merge --continue）或放弃合并（cit merge --abort）")
+	}
+
+	// 计算当前快照和目标快照
+	var currentHead string
+	if head, err := r.HeadCommit(); err == nil {
+		currentHead = head
+	}
+
+	currentFiles, err := r.commitTreeFiles(currentHead)
+	if err != nil {
+		return fmt.Errorf("读取当前提交失败: %v", err)
+	}
+
+	targetFiles, err := r.commitTreeFiles(targetHead)
+	if err != nil {
+		return fmt.Errorf("读取目标提交失败: %v", err)
+	}
+
+	// 更新工作目录
+	if err := r.switchTree(currentFiles, targetFiles, force); err != nil {
+		return err
+	}
+
+	if force {
+		if err := r.Storage.ClearStaging(); err != nil {
+			return fmt.Errorf("清空暂存区失败: %v", err)
+		}
+		if err := r.Storage.ClearMergeState(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCurrentBranch 获取当前分支名，分离状态下返回空字符串
 func (r *Repository) GetCurrentBranch() string {
 	return r.CurrentBranch
 }
 
+// IsDetached 判断 HEAD 是否处于分离状态
+func (r *Repository) IsDetached() bool {
+	return r.CurrentBranch == ""
+}
+
+// HeadCommit 返回 HEAD 指向的提交ID，当前分支还没有提交时返回空字符串
+func (r *Repository) HeadCommit() (string, error) {
+	if r.IsDetached() {
+		return r.DetachedHead, nil
+	}
+	return r.Storage.GetBranchHead(r.CurrentBranch)
+}
+
+// updateHead 移动 HEAD：指向分支时更新分支头，分离状态下直接改写 HEAD。
+// reason 记录到 HEAD 和当前分支的引用日志中。
+func (r *Repository) updateHead(commitID, reason string) error {
+	oldHead, _ := r.HeadCommit()
+
+	if !r.IsDetached() {
+		if err := r.Storage.UpdateBranchHead(r.CurrentBranch, commitID); err != nil {
+			return err
+		}
+	} else {
+		if err := r.Storage.SetHeadDetached(commitID); err != nil {
+			return err
+		}
+		r.DetachedHead = commitID
+	}
+
+	return r.logRefUpdate(oldHead, commitID, reason, true)
+}
+
+// headName 返回提示信息中使用的 HEAD 名称
+func (r *Repository) headName() string {
+	if r.IsDetached() {
+		return "HEAD"
+	}
+	return r.CurrentBranch
+}
+
 // IsStagingEmpty 检查暂存区是否为空
 func (r *Repository) IsStagingEmpty() bool {
 	staging, err := r.Storage.GetStaging()
@@ -309,8 +616,8 @@ func (r *Repository) Push(remoteName, branchName string) error {
 		return fmt.Errorf("远程仓库 '%s' 不存在", remoteName)
 	}
 
-	// 获取当前分支的提交
-	commits, err := r.Storage.GetCommitHistory()
+	// 获取待推送分支的提交
+	commits, err := r.Log(LogOptions{Start: branchName})
 	if err != nil {
 		return fmt.Errorf("获取提交历史失败: %v", err)
 	}
@@ -336,7 +643,11 @@ func (r *Repository) Push(remoteName, branchName string) error {
 // 私有方法
 
 func (r *Repository) save() error {
-	repoFile := filepath.Join(r.Path, ".cit-version01-无法批量提交", "repository.json")
+	// .git 目录可能属于普通的Git仓库，不写入cit自己的仓库信息，打开时由路径推导
+	if r.Storage.Format() == storage.FormatGit {
+		return nil
+	}
+	repoFile := filepath.Join(r.gitDir, "repository.json")
 	data, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
 		return err
@@ -344,26 +655,55 @@ func (r *Repository) save() error {
 	return os.WriteFile(repoFile, data, 0644)
 }
 
-func loadRepository(gitDir string) (*Repository, error) {
-	repoFile := filepath.Join(gitDir, "repository.json")
-	data, err := os.ReadFile(repoFile)
-	if err != nil {
-		return nil, err
+func loadRepository(gitDir, format string) (*Repository, error) {
+	var repo struct {
+		Repository
+		LegacyBranch string `json:"current_branch"`
 	}
 
-	var repo Repository
-	if err := json.Unmarshal(data, &repo); err != nil {
+	repoFile := filepath.Join(gitDir, "repository.json")
+	data, err := os.ReadFile(repoFile)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &repo); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err) && format == storage.FormatGit:
+		// Git格式的仓库不保存 repository.json，仓库ID由路径推导，创建时间未知
+		path := filepath.Dir(gitDir)
+		repo.Repository = Repository{ID: gitRepositoryID(path), Path: path}
+	default:
 		return nil, err
 	}
+	repo.gitDir = gitDir
 
 	// 重新初始化存储
-	storage, err := storage.NewStorage(gitDir)
+	storage, err := storage.NewStorage(gitDir, format)
 	if err != nil {
 		return nil, err
 	}
 	repo.Storage = storage
 
-	return &repo, nil
+	// 当前分支记录在 HEAD 中，旧版本仓库从 repository.json 迁移
+	branch, detached, err := storage.ReadHead()
+	if os.IsNotExist(err) {
+		branch = repo.LegacyBranch
+		if branch == "" {
+			branch = "main"
+		}
+		if err := storage.SetHeadBranch(branch); err != nil {
+			return nil, fmt.Errorf("迁移当前分支失败: %v", err)
+		}
+		if err := repo.Repository.save(); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("读取HEAD失败: %v", err)
+	}
+	repo.CurrentBranch = branch
+	repo.DetachedHead = detached
+
+	return &repo.Repository, nil
 }
 
 func generateRepositoryID(path string) string {
@@ -372,19 +712,118 @@ func generateRepositoryID(path string) string {
 	return fmt.Sprintf("%x", hash[:8])
 }
 
+// gitRepositoryID 由工作目录路径推导Git格式仓库的ID，同一仓库每次打开得到相同的ID
+func gitRepositoryID(path string) string {
+	hash := sha1.Sum([]byte(path))
+	return fmt.Sprintf("%x", hash[:8])
+}
+
 func getCurrentUser() string {
 	// 这里应该从环境变量或配置文件获取用户信息
-	// 简化实现，返回默认值
-	return "user@example.com"
+	// 简化实现，返回默认值（使用Git的 "姓名 <邮箱>" 格式）
+	return "user <user@example.com>"
 }
 
-func (r *Repository) getWorkdirStatus() (*WorkdirStatus, error) {
-	// 这里应该实现工作目录状态检查
-	// 简化实现，返回空状态
-	return &WorkdirStatus{
+// getWorkdirStatus 以 HEAD 快照叠加暂存区作为基准，检查工作目录中的变更
+func (r *Repository) getWorkdirStatus(headFiles map[string]*storage.TreeEntry, staging map[string]string) (*WorkdirStatus, error) {
+	status := &WorkdirStatus{
 		ModifiedFiles:  []string{},
+		DeletedFiles:   []string{},
 		UntrackedFiles: []string{},
-	}, nil
+	}
+
+	indexFiles := stagedSnapshot(headFiles, staging)
+
+	workFiles, err := r.getWorkingDirectoryFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	inWorkdir := make(map[string]bool, len(workFiles))
+	for _, filePath := range workFiles {
+		inWorkdir[filePath] = true
+
+		expected, tracked := indexFiles[filePath]
+		if !tracked {
+			status.UntrackedFiles = append(status.UntrackedFiles, filePath)
+			continue
+		}
+
+		if hash, ok := r.workingFileHash(filePath); ok && hash != expected {
+			status.ModifiedFiles = append(status.ModifiedFiles, filePath)
+		}
+	}
+
+	for filePath := range indexFiles {
+		if !inWorkdir[filePath] {
+			status.DeletedFiles = append(status.DeletedFiles, filePath)
+		}
+	}
+
+	sort.Strings(status.ModifiedFiles)
+	sort.Strings(status.DeletedFiles)
+	sort.Strings(status.UntrackedFiles)
+	return status, nil
+}
+
+// detectRenames 将内容相同的暂存删除和暂存新文件配对为重命名，
+// 返回 "旧路径 -> 新路径" 形式的重命名列表以及剩余的新文件和删除
+func detectRenames(headFiles map[string]*storage.TreeEntry, staging map[string]string, added, deleted []string) (renamed, newFiles, deletedFiles []string) {
+	sort.Strings(added)
+	sort.Strings(deleted)
+
+	addedByHash := make(map[string][]string)
+	for _, filePath := range added {
+		hash := staging[filepath.FromSlash(filePath)]
+		addedByHash[hash] = append(addedByHash[hash], filePath)
+	}
+
+	for _, filePath := range deleted {
+		hash := headFiles[filePath].Hash
+		if candidates := addedByHash[hash]; len(candidates) > 0 {
+			renamed = append(renamed, filePath+" -> "+candidates[0])
+			addedByHash[hash] = candidates[1:]
+			continue
+		}
+		deletedFiles = append(deletedFiles, filePath)
+	}
+
+	for _, filePath := range added {
+		hash := staging[filepath.FromSlash(filePath)]
+		if len(addedByHash[hash]) > 0 && addedByHash[hash][0] == filePath {
+			newFiles = append(newFiles, filePath)
+			addedByHash[hash] = addedByHash[hash][1:]
+		}
+	}
+	return renamed, newFiles, deletedFiles
+}
+
+// withoutPaths 过滤掉出现在 exclude 中的路径
+func withoutPaths(files []string, exclude map[string]*storage.ConflictEntry) []string {
+	result := files[:0]
+	for _, file := range files {
+		if _, ok := exclude[file]; !ok {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// stagedSnapshot 返回 HEAD 快照叠加暂存区后的 路径 -> 哈希 映射
+func stagedSnapshot(headFiles map[string]*storage.TreeEntry, staging map[string]string) map[string]string {
+	files := make(map[string]string, len(headFiles)+len(staging))
+	for filePath, entry := range headFiles {
+		files[filePath] = entry.Hash
+	}
+	for file, hash := range staging {
+		filePath := filepath.ToSlash(file)
+		if hash == "" {
+			delete(files, filePath)
+		} else {
+			files[filePath] = hash
+		}
+	}
+	return files
 }
 
 // getWorkingDirectoryFiles 获取工作目录中的所有文件
@@ -397,13 +836,11 @@ func (r *Repository) getWorkingDirectoryFiles() ([]string, error) {
 			return err
 		}
 
-		// 跳过目录
+		// 跳过仓库目录和隐藏目录
 		if info.IsDir() {
-			return nil
-		}
-
-		// 跳过.cit目录
-		if strings.Contains(path, ".cit-version01-无法批量提交") {
+			if path != r.Path && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -423,7 +860,7 @@ func (r *Repository) getWorkingDirectoryFiles() ([]string, error) {
 			return nil
 		}
 
-		files = append(files, relPath)
+		files = append(files, filepath.ToSlash(relPath))
 		return nil
 	})
 
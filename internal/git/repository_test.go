@@ -0,0 +1,73 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cit/internal/storage"
+)
+
+func TestGitFormatLeavesGitDirUntouched(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := InitRepositoryFormat(dir, storage.FormatGit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repoFile := filepath.Join(dir, GitDirName, "repository.json")
+	if _, err := os.Stat(repoFile); !os.IsNotExist(err) {
+		t.Fatalf(".git 中不应写入 repository.json")
+	}
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	found, err := FindRepository(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != repo.ID {
+		t.Fatalf("仓库ID应由路径推导且保持不变: %s != %s", found.ID, repo.ID)
+	}
+	if _, err := found.GetStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(repoFile); !os.IsNotExist(err) {
+		t.Fatalf("打开仓库后 .git 中不应出现 repository.json")
+	}
+}
+
+func TestFindRepositoryRequiresMarkerForGitDir(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := storage.NewStorage(filepath.Join(dir, GitDirName), storage.FormatGit); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, GitDirName, "HEAD"), []byte("ref: refs/heads/master\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := FindRepository(dir); err == nil {
+		t.Fatalf("没有 cit 标记的 .git 目录不应被打开")
+	}
+
+	AllowGitDir = true
+	_, err := FindRepository(dir)
+	AllowGitDir = false
+	if err != nil {
+		t.Fatalf("设置 AllowGitDir 后应能打开: %v", err)
+	}
+
+	config := filepath.Join(dir, GitDirName, "config")
+	data, err := os.ReadFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, append(data, "[cit]\n\trepository = true\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindRepository(dir); err != nil {
+		t.Fatalf("带有 cit 标记的 .git 目录应能打开: %v", err)
+	}
+}
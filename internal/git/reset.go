@@ -0,0 +1,164 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"cit/internal/storage"
+)
+
+// ResetMode 决定 reset 移动 HEAD 之后如何处理暂存区和工作目录
+type ResetMode string
+
+const (
+	ResetSoft  ResetMode = "soft"  // 只移动 HEAD，暂存区和工作目录保持不变
+	ResetMixed ResetMode = "mixed" // 移动 HEAD 并将暂存区重置为目标快照
+	ResetHard  ResetMode = "hard"  // 移动 HEAD，暂存区和工作目录都重置为目标快照
+)
+
+// Reset 将当前分支（或分离的 HEAD）移动到指定修订，返回目标提交ID
+func (r *Repository) Reset(rev string, mode ResetMode) (string, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	target, err := r.ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := r.Storage.GetMergeState()
+	if err != nil {
+		return "", err
+	}
+	if state != nil && mode == ResetSoft {
+		return "", fmt.Errorf("合并进行中，不能使用 --soft 重置")
+	}
+
+	headFiles, err := r.headFiles()
+	if err != nil {
+		return "", err
+	}
+	indexFiles, err := r.indexFiles()
+	if err != nil {
+		return "", err
+	}
+	targetFiles, err := r.commitTreeFiles(target)
+	if err != nil {
+		return "", err
+	}
+
+	switch mode {
+	case ResetSoft:
+		// 暂存区是相对 HEAD 的差异，HEAD 移动后需要重新计算以保持暂存内容不变
+		entries := make(map[string]string)
+		for filePath, entry := range indexFiles {
+			if t, ok := targetFiles[filePath]; !ok || t.Hash != entry.Hash {
+				entries[filepath.FromSlash(filePath)] = entry.Hash
+			}
+		}
+		for filePath := range targetFiles {
+			if _, ok := indexFiles[filePath]; !ok {
+				entries[filepath.FromSlash(filePath)] = ""
+			}
+		}
+		if err := r.Storage.SetStaging(entries); err != nil {
+			return "", err
+		}
+
+	case ResetMixed, ResetHard:
+		if mode == ResetHard {
+			// 丢弃所有已跟踪文件的本地修改，未跟踪的文件保持不动
+			tracked := make(map[string]*storage.TreeEntry, len(headFiles)+len(indexFiles))
+			for filePath, entry := range headFiles {
+				tracked[filePath] = entry
+			}
+			for filePath, entry := range indexFiles {
+				tracked[filePath] = entry
+			}
+			if err := r.switchTree(tracked, targetFiles, true); err != nil {
+				return "", err
+			}
+		}
+		if err := r.Storage.ClearStaging(); err != nil {
+			return "", err
+		}
+		if err := r.Storage.ClearMergeState(); err != nil {
+			return "", err
+		}
+
+	default:
+		return "", fmt.Errorf("未知的重置模式: %s", mode)
+	}
+
+	if err := r.updateHead(target, "reset: moving to "+rev); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// ResetPaths 将指定路径（文件或目录，绝对路径）在暂存区中的内容重置为修订中的版本，
+// 修订为空时使用 HEAD，即取消暂存。工作目录不受影响。返回被重置的文件。
+func (r *Repository) ResetPaths(rev string, paths []string) ([]string, error) {
+	headFiles, err := r.headFiles()
+	if err != nil {
+		return nil, err
+	}
+	targetFiles := headFiles
+	if rev != "" {
+		if targetFiles, err = r.revisionFiles(rev); err != nil {
+			return nil, err
+		}
+	}
+
+	indexFiles, err := r.indexFiles()
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := r.Storage.GetConflicts()
+	if err != nil {
+		return nil, err
+	}
+
+	// 候选文件：暂存区、目标快照以及冲突中出现的所有路径
+	candidates := make(map[string]bool)
+	for filePath := range indexFiles {
+		candidates[filePath] = true
+	}
+	for filePath := range targetFiles {
+		candidates[filePath] = true
+	}
+	for filePath := range conflicts {
+		candidates[filepath.ToSlash(filePath)] = true
+	}
+
+	matched, err := r.matchPaths(paths, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var reset []string
+	for _, filePath := range matched {
+		target, inTarget := targetFiles[filePath]
+		head, inHead := headFiles[filePath]
+		osPath := filepath.FromSlash(filePath)
+
+		switch {
+		case inTarget == inHead && (!inTarget || target.Hash == head.Hash):
+			// 目标版本与HEAD相同，只需去掉暂存条目
+			err = r.Storage.RemoveFromStaging(osPath)
+		case inTarget:
+			err = r.Storage.AddToStaging(osPath, target.Hash)
+		default:
+			err = r.Storage.AddToStaging(osPath, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if index, staged := indexFiles[filePath]; staged != inTarget || (staged && index.Hash != target.Hash) {
+			reset = append(reset, filePath)
+		}
+	}
+
+	return reset, nil
+}
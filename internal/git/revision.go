@@ -0,0 +1,208 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 最短可用的缩写哈希长度
+const minAbbrevLength = 4
+
+var hexPattern = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// RevisionRange 表示 A..B 或 A...B 形式的修订范围
+type RevisionRange struct {
+	From      string // 范围起点的提交ID
+	To        string // 范围终点的提交ID
+	Symmetric bool   // A...B：只属于其中一侧的提交
+}
+
+// ParseRange 解析修订范围，省略的一侧默认为 HEAD。表达式不是范围时返回 nil。
+func (r *Repository) ParseRange(expr string) (*RevisionRange, error) {
+	symmetric := true
+	from, to, ok := strings.Cut(expr, "...")
+	if !ok {
+		symmetric = false
+		if from, to, ok = strings.Cut(expr, ".."); !ok {
+			return nil, nil
+		}
+	}
+
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	fromID, err := r.ResolveRevision(from)
+	if err != nil {
+		return nil, err
+	}
+	toID, err := r.ResolveRevision(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionRange{From: fromID, To: toID, Symmetric: symmetric}, nil
+}
+
+// ResolveRevision 将修订表达式解析为提交ID，支持：
+//
+//	HEAD、@            HEAD 指向的提交
+//	<标签>              标签指向的提交（附注标签会被展开）
+//	<分支>              分支头
+//	<哈希>              完整或唯一的缩写哈希（至少4位，不区分大小写）
+//	<修订>@{N}          引用日志中的第N个历史位置
+//	<修订>~N            沿第一个父提交回溯N代
+//	<修订>^N            第N个父提交，^0 表示提交本身
+func (r *Repository) ResolveRevision(rev string) (string, error) {
+	if rev == "" {
+		return "", fmt.Errorf("修订不能为空")
+	}
+
+	// 拆分基础名称和祖先后缀
+	baseEnd := strings.IndexAny(rev, "~^")
+	if baseEnd < 0 {
+		baseEnd = len(rev)
+	}
+
+	id, err := r.resolveRevisionBase(rev[:baseEnd])
+	if err != nil {
+		return "", err
+	}
+
+	for suffix := rev[baseEnd:]; suffix != ""; {
+		op := suffix[0]
+		digits := 0
+		for digits+1 < len(suffix) && suffix[digits+1] >= '0' && suffix[digits+1] <= '9' {
+			digits++
+		}
+
+		n := 1
+		if digits > 0 {
+			if n, err = strconv.Atoi(suffix[1 : digits+1]); err != nil {
+				return "", fmt.Errorf("无效的修订: %s", rev)
+			}
+		}
+		suffix = suffix[digits+1:]
+
+		if id, err = r.commitAncestor(id, op, n); err != nil {
+			return "", fmt.Errorf("无法解析 '%s': %v", rev, err)
+		}
+	}
+
+	return id, nil
+}
+
+// commitAncestor 按后缀运算符取祖先：'~' 沿第一个父提交回溯n代，'^' 取第n个父提交
+func (r *Repository) commitAncestor(id string, op byte, n int) (string, error) {
+	if op == '~' {
+		for i := 0; i < n; i++ {
+			commit, err := r.Storage.GetCommit(id)
+			if err != nil {
+				return "", err
+			}
+			if commit.FirstParent() == "" {
+				return "", fmt.Errorf("提交 %s 没有父提交", shortID(id))
+			}
+			id = commit.FirstParent()
+		}
+		return id, nil
+	}
+
+	if n == 0 {
+		return id, nil
+	}
+	commit, err := r.Storage.GetCommit(id)
+	if err != nil {
+		return "", err
+	}
+	if n > len(commit.Parents) {
+		return "", fmt.Errorf("提交 %s 没有第 %d 个父提交", shortID(id), n)
+	}
+	return commit.Parents[n-1], nil
+}
+
+// resolveRevisionBase 解析不带祖先后缀的修订
+func (r *Repository) resolveRevisionBase(name string) (string, error) {
+	if ref, selector, ok := strings.Cut(name, "@{"); ok && strings.HasSuffix(selector, "}") {
+		n, err := strconv.Atoi(strings.TrimSuffix(selector, "}"))
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("无效的引用日志位置: %s", name)
+		}
+		return r.resolveReflog(ref, n)
+	}
+
+	if name == "HEAD" || name == "@" {
+		head, err := r.HeadCommit()
+		if err != nil {
+			return "", err
+		}
+		if head == "" {
+			return "", fmt.Errorf("当前分支 '%s' 还没有提交", r.CurrentBranch)
+		}
+		return head, nil
+	}
+
+	// 与Git一致，同名时标签优先于分支
+	if _, err := r.Storage.GetTagRef(name); err == nil {
+		tag, err := r.GetTag(name)
+		if err != nil {
+			return "", err
+		}
+		return tag.Commit, nil
+	}
+
+	if head, err := r.Storage.GetBranchHead(name); err == nil {
+		if head == "" {
+			return "", fmt.Errorf("分支 '%s' 还没有提交", name)
+		}
+		return head, nil
+	}
+
+	// 与Git一致，哈希不区分大小写
+	if lower := strings.ToLower(name); len(lower) >= minAbbrevLength && hexPattern.MatchString(lower) {
+		return r.resolveAbbrev(lower)
+	}
+
+	return "", fmt.Errorf("无法识别的修订: %s", name)
+}
+
+// resolveAbbrev 将唯一的缩写哈希解析为提交ID，有歧义时列出所有候选对象
+func (r *Repository) resolveAbbrev(prefix string) (string, error) {
+	hashes, err := r.Storage.FindObjects(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(hashes) {
+	case 0:
+		return "", fmt.Errorf("无法识别的修订: %s", prefix)
+	case 1:
+		if _, err := r.Storage.GetCommit(hashes[0]); err != nil {
+			return "", fmt.Errorf("对象 %s 不是提交", hashes[0])
+		}
+		return hashes[0], nil
+	}
+
+	var candidates []string
+	for _, hash := range hashes {
+		objectType, err := r.Storage.ObjectType(hash)
+		if err != nil {
+			objectType = "未知"
+		}
+		candidates = append(candidates, fmt.Sprintf("%s %s", hash, objectType))
+	}
+	return "", fmt.Errorf("短哈希 '%s' 有歧义，候选对象:\n  %s", prefix, strings.Join(candidates, "\n  "))
+}
+
+// shortID 返回提交ID的前7位，用于提示信息
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
@@ -0,0 +1,244 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveRevisionAfterGC(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := InitRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		file := filepath.Join(dir, "a.txt")
+		if err := os.WriteFile(file, []byte(fmt.Sprintf("version %d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AddToStaging(file); err != nil {
+			t.Fatal(err)
+		}
+		commit, err := repo.Commit(fmt.Sprintf("commit %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, commit.ID)
+	}
+
+	if _, err := repo.Storage.GC(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	for rev, want := range map[string]string{
+		ids[0]:     ids[0],
+		ids[1][:7]: ids[1],
+		"HEAD~1":   ids[1],
+		"main~2":   ids[0],
+	} {
+		got, err := repo.ResolveRevision(rev)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", rev, err)
+		}
+		if got != want {
+			t.Fatalf("解析 %s 得到 %s，期望 %s", rev, got, want)
+		}
+	}
+
+	if _, err := repo.CheckoutCommit(ids[0][:8], false); err != nil {
+		t.Fatalf("切换到已打包的提交失败: %v", err)
+	}
+}
+
+// revisionHistory 建立如下历史，返回各提交的ID：
+//
+//	c1 - c2 - c3 - m   (main，c2 上有标签 v1)
+//	  \          /
+//	   f1 -------      (feature)
+func revisionHistory(t *testing.T) (*Repository, map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := InitRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]string)
+	commit := func(name, file string) {
+		t.Helper()
+		path := filepath.Join(dir, file)
+		if err := os.WriteFile(path, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AddToStaging(path); err != nil {
+			t.Fatal(err)
+		}
+		c, err := repo.Commit(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = c.ID
+	}
+
+	commit("c1", "a.txt")
+	if err := repo.CreateBranch("feature"); err != nil {
+		t.Fatal(err)
+	}
+	commit("c2", "a.txt")
+	commit("c3", "a.txt")
+	if err := repo.CheckoutBranch("feature", false); err != nil {
+		t.Fatal(err)
+	}
+	commit("f1", "b.txt")
+	if err := repo.CheckoutBranch("main", false); err != nil {
+		t.Fatal(err)
+	}
+	result, err := repo.MergeBranch("feature", MergeOptions{})
+	if err != nil || !result.Success {
+		t.Fatalf("合并失败: %v %+v", err, result)
+	}
+	ids["m"] = result.CommitID
+	if _, err := repo.CreateTag("v1", ids["c2"], "", false); err != nil {
+		t.Fatal(err)
+	}
+	return repo, ids
+}
+
+func TestResolveRevision(t *testing.T) {
+	repo, ids := revisionHistory(t)
+
+	cases := []struct {
+		rev  string
+		want string
+	}{
+		{"HEAD", "m"},
+		{"@", "m"},
+		{"main", "m"},
+		{"feature", "f1"},
+		{"v1", "c2"},
+		{"HEAD^", "c3"},
+		{"HEAD^1", "c3"},
+		{"HEAD^2", "f1"},
+		{"HEAD^0", "m"},
+		{"HEAD^^", "c2"},
+		{"HEAD~", "c3"},
+		{"HEAD~2", "c2"},
+		{"HEAD~3", "c1"},
+		{"HEAD^2~1", "c1"},
+		{"HEAD^2^", "c1"},
+		{"v1~1", "c1"},
+		{"main@{0}", "m"},
+		{"main@{1}", "c3"},
+		{"main@{2}", "c2"},
+		{"feature@{0}~1", "c1"},
+		{"@{0}", "m"},
+		{ids["c2"], "c2"},
+		{ids["c2"][:4], "c2"},
+		{ids["c2"][:7], "c2"},
+		{strings.ToUpper(ids["c2"][:7]), "c2"},
+		{strings.ToUpper(ids["c2"]), "c2"},
+		{ids["c3"][:7] + "~1", "c2"},
+	}
+	for _, c := range cases {
+		got, err := repo.ResolveRevision(c.rev)
+		if err != nil {
+			t.Errorf("%s: %v", c.rev, err)
+			continue
+		}
+		if got != ids[c.want] {
+			t.Errorf("%s 解析为 %s，期望 %s (%s)", c.rev, got, ids[c.want], c.want)
+		}
+	}
+}
+
+func TestResolveRevisionErrors(t *testing.T) {
+	repo, ids := revisionHistory(t)
+
+	// 找到两个前4位相同的文件对象，构造有歧义的缩写
+	seen := make(map[string]string)
+	var ambiguous string
+	for i := 0; ambiguous == ""; i++ {
+		hash, err := repo.Storage.StoreBlob([]byte(fmt.Sprintf("blob %d\n", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := seen[hash[:4]]; ok {
+			ambiguous = hash[:4]
+		}
+		seen[hash[:4]] = hash
+	}
+	blob, err := repo.Storage.StoreBlob([]byte("not a commit\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		rev     string
+		message string
+	}{
+		{"", "不能为空"},
+		{"nope", "无法识别"},
+		{ids["c2"][:3], "无法识别"},
+		{"HEAD^3", "没有第 3 个父提交"},
+		{"HEAD~4", "没有父提交"},
+		{"HEAD~2^2", "没有第 2 个父提交"},
+		{"main@{99}", "超出范围"},
+		{"main@{x}", "无效的引用日志位置"},
+		{"main@{-1}", "无效的引用日志位置"},
+		{ambiguous, "有歧义"},
+		{blob[:10], "不是提交"},
+	}
+	for _, c := range cases {
+		_, err := repo.ResolveRevision(c.rev)
+		if err == nil {
+			t.Errorf("%q 应当解析失败", c.rev)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.message) {
+			t.Errorf("%q 的错误 %q 中没有 %q", c.rev, err, c.message)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	repo, ids := revisionHistory(t)
+
+	cases := []struct {
+		expr      string
+		from, to  string
+		symmetric bool
+	}{
+		{ids["c1"][:7] + "..HEAD", "c1", "m", false},
+		{"v1..main", "c2", "m", false},
+		{"main..feature", "m", "f1", false},
+		{"..feature", "m", "f1", false},
+		{"feature..", "f1", "m", false},
+		{"main...feature", "m", "f1", true},
+		{"HEAD~1...HEAD^2", "c3", "f1", true},
+	}
+	for _, c := range cases {
+		rng, err := repo.ParseRange(c.expr)
+		if err != nil {
+			t.Errorf("%s: %v", c.expr, err)
+			continue
+		}
+		if rng == nil || rng.From != ids[c.from] || rng.To != ids[c.to] || rng.Symmetric != c.symmetric {
+			t.Errorf("%s 解析为 %+v，期望 %s..%s (对称: %v)", c.expr, rng, c.from, c.to, c.symmetric)
+		}
+	}
+
+	if rng, err := repo.ParseRange("main"); rng != nil || err != nil {
+		t.Errorf("不是范围的表达式应返回 nil: %+v, %v", rng, err)
+	}
+	for _, expr := range []string{"nope..main", "main...nope", "HEAD~9..HEAD"} {
+		if _, err := repo.ParseRange(expr); err == nil {
+			t.Errorf("%s 应当解析失败", expr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"cit/internal/storage"
+)
+
+// ResolveCommit 解析修订并读取对应的提交
+func (r *Repository) ResolveCommit(rev string) (*storage.Commit, error) {
+	id, err := r.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+	return r.Storage.GetCommit(id)
+}
+
+// CommitChanges 返回提交相对于第一个父提交的文件变化，根提交与空快照比较
+func (r *Repository) CommitChanges(commit *storage.Commit) ([]*FileChange, error) {
+	parentFiles, err := r.commitTreeFiles(commit.FirstParent())
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := r.listTreeFiles(commit.TreeHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.diffSnapshots(parentFiles, files, false)
+}
+
+// ResolvePath 在修订的快照中查找路径对应的条目，路径为空时返回根树
+func (r *Repository) ResolvePath(rev, filePath string) (*storage.TreeEntry, error) {
+	commit, err := r.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &storage.TreeEntry{
+		Mode: storage.ModeTree,
+		Type: storage.TypeTree,
+		Hash: commit.TreeHash,
+	}
+
+	for _, name := range strings.Split(strings.Trim(filePath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		if !entry.IsTree() {
+			return nil, fmt.Errorf("路径 '%s' 在 %s 中不存在", filePath, rev)
+		}
+
+		tree, err := r.Storage.GetTree(entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		var found *storage.TreeEntry
+		for _, child := range tree.Entries {
+			if child.Name == name {
+				found = child
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("路径 '%s' 在 %s 中不存在", filePath, rev)
+		}
+		entry = found
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"cit/internal/storage"
+)
+
+// TagInfo 描述一个标签及其最终指向的提交
+type TagInfo struct {
+	Name   string
+	Commit string       // 标签最终指向的提交ID
+	Tag    *storage.Tag // 附注标签对象，轻量标签为 nil
+}
+
+// CreateTag 在指定修订（为空时为 HEAD）上创建标签。
+// annotated 为 true 时创建附注标签对象，记录标记者、时间和说明。
+func (r *Repository) CreateTag(name, rev, message string, annotated bool) (*TagInfo, error) {
+	if err := storage.CheckTagName(name); err != nil {
+		return nil, err
+	}
+	if _, err := r.Storage.GetTagRef(name); err == nil {
+		return nil, fmt.Errorf("标签 '%s' 已存在", name)
+	}
+
+	if rev == "" {
+		rev = "HEAD"
+	}
+	commitID, err := r.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TagInfo{Name: name, Commit: commitID}
+	target := commitID
+	if annotated {
+		if message == "" {
+			return nil, fmt.Errorf("附注标签需要说明，请使用 -m 指定")
+		}
+		tag := &storage.Tag{
+			Object:     commitID,
+			ObjectType: storage.TypeCommit,
+			Name:       name,
+			Tagger:     getCurrentUser(),
+			Timestamp:  time.Unix(time.Now().Unix(), 0),
+			Message:    message,
+		}
+		if err := r.Storage.StoreTag(tag); err != nil {
+			return nil, err
+		}
+		info.Tag = tag
+		target = tag.ID
+	}
+
+	if err := r.Storage.CreateTagRef(name, target); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ListTags 列出名称匹配通配符模式的标签，模式为空时列出全部
+func (r *Repository) ListTags(pattern string) ([]*TagInfo, error) {
+	refs, err := r.Storage.ListTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*TagInfo
+	for _, ref := range refs {
+		if pattern != "" {
+			matched, err := path.Match(pattern, ref.Name)
+			if err != nil {
+				return nil, fmt.Errorf("无效的匹配模式 '%s': %v", pattern, err)
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		info, err := r.tagInfo(ref.Name, ref.Target)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, info)
+	}
+	return tags, nil
+}
+
+// GetTag 读取指定标签
+func (r *Repository) GetTag(name string) (*TagInfo, error) {
+	target, err := r.Storage.GetTagRef(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.tagInfo(name, target)
+}
+
+// DeleteTag 删除标签
+func (r *Repository) DeleteTag(name string) error {
+	return r.Storage.DeleteTagRef(name)
+}
+
+// tagInfo 沿标签对象链找到最终指向的提交
+func (r *Repository) tagInfo(name, target string) (*TagInfo, error) {
+	info := &TagInfo{Name: name}
+
+	id := target
+	for {
+		objectType, err := r.Storage.ObjectType(id)
+		if err != nil {
+			return nil, fmt.Errorf("标签 '%s' 指向的对象无效: %v", name, err)
+		}
+		if objectType != storage.TypeTag {
+			if objectType != storage.TypeCommit {
+				return nil, fmt.Errorf("标签 '%s' 指向的是%s对象而不是提交", name, objectType)
+			}
+			info.Commit = id
+			return info, nil
+		}
+
+		tag, err := r.Storage.GetTag(id)
+		if err != nil {
+			return nil, err
+		}
+		if info.Tag == nil {
+			info.Tag = tag
+		}
+		id = tag.Object
+	}
+}
@@ -0,0 +1,168 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cit/internal/storage"
+)
+
+// treeNode 是构建树对象时使用的内存目录结构
+type treeNode struct {
+	files map[string]*storage.TreeEntry
+	dirs  map[string]*treeNode
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{
+		files: make(map[string]*storage.TreeEntry),
+		dirs:  make(map[string]*treeNode),
+	}
+}
+
+// listTreeFiles 递归展开树对象，返回 路径 -> 文件条目 的映射（路径使用"/"分隔）
+func (r *Repository) listTreeFiles(treeHash string) (map[string]*storage.TreeEntry, error) {
+	files := make(map[string]*storage.TreeEntry)
+	if treeHash == "" {
+		return files, nil
+	}
+
+	if err := r.walkTree(treeHash, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (r *Repository) walkTree(treeHash, prefix string, files map[string]*storage.TreeEntry) error {
+	tree, err := r.Storage.GetTree(treeHash)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range tree.Entries {
+		fullPath := path.Join(prefix, entry.Name)
+		if entry.IsTree() {
+			if err := r.walkTree(entry.Hash, fullPath, files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[fullPath] = &storage.TreeEntry{
+			Mode: entry.Mode,
+			Type: entry.Type,
+			Hash: entry.Hash,
+			Name: fullPath,
+		}
+	}
+	return nil
+}
+
+// commitTreeFiles 返回指定提交快照中的所有文件，提交ID为空时返回空映射
+func (r *Repository) commitTreeFiles(commitID string) (map[string]*storage.TreeEntry, error) {
+	if commitID == "" {
+		return make(map[string]*storage.TreeEntry), nil
+	}
+
+	commit, err := r.Storage.GetCommit(commitID)
+	if err != nil {
+		return nil, err
+	}
+	return r.listTreeFiles(commit.TreeHash)
+}
+
+// buildTree 以父提交的树为基础叠加暂存区内容，写入层级树对象并返回根树哈希
+func (r *Repository) buildTree(parentTree string, staging map[string]string) (string, error) {
+	files, err := r.listTreeFiles(parentTree)
+	if err != nil {
+		return "", fmt.Errorf("读取父提交的树失败: %v", err)
+	}
+
+	for filePath, hash := range staging {
+		slashPath := filepath.ToSlash(filePath)
+		if hash == "" {
+			// 空哈希表示暂存的删除
+			delete(files, slashPath)
+			continue
+		}
+		files[slashPath] = &storage.TreeEntry{
+			Mode: r.fileMode(slashPath),
+			Type: storage.TypeBlob,
+			Hash: hash,
+			Name: slashPath,
+		}
+	}
+
+	return r.writeTree(files)
+}
+
+// writeTree 将扁平的文件映射写成层级树对象
+func (r *Repository) writeTree(files map[string]*storage.TreeEntry) (string, error) {
+	root := newTreeNode()
+	for filePath, entry := range files {
+		node := root
+		parts := strings.Split(filePath, "/")
+		for _, dir := range parts[:len(parts)-1] {
+			child, ok := node.dirs[dir]
+			if !ok {
+				child = newTreeNode()
+				node.dirs[dir] = child
+			}
+			node = child
+		}
+		node.files[parts[len(parts)-1]] = entry
+	}
+
+	return r.storeTreeNode(root)
+}
+
+func (r *Repository) storeTreeNode(node *treeNode) (string, error) {
+	tree := &storage.Tree{}
+
+	dirNames := make([]string, 0, len(node.dirs))
+	for name := range node.dirs {
+		dirNames = append(dirNames, name)
+	}
+	sort.Strings(dirNames)
+
+	for _, name := range dirNames {
+		hash, err := r.storeTreeNode(node.dirs[name])
+		if err != nil {
+			return "", err
+		}
+		tree.Entries = append(tree.Entries, &storage.TreeEntry{
+			Mode: storage.ModeTree,
+			Type: storage.TypeTree,
+			Hash: hash,
+			Name: name,
+		})
+	}
+
+	for name, entry := range node.files {
+		// 子模块条目的类型是提交，其余文件条目都是文件对象
+		entryType := entry.Type
+		if entryType == "" {
+			entryType = storage.TypeBlob
+		}
+		tree.Entries = append(tree.Entries, &storage.TreeEntry{
+			Mode: entry.Mode,
+			Type: entryType,
+			Hash: entry.Hash,
+			Name: name,
+		})
+	}
+
+	return r.Storage.StoreTree(tree)
+}
+
+// fileMode 根据工作目录中的文件权限确定条目模式
+func (r *Repository) fileMode(relPath string) string {
+	info, err := os.Stat(filepath.Join(r.Path, filepath.FromSlash(relPath)))
+	if err == nil && info.Mode()&0111 != 0 {
+		return storage.ModeExecutable
+	}
+	return storage.ModeFile
+}
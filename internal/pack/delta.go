@@ -0,0 +1,238 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errDeltaTruncated = errors.New("增量数据被截断")
+
+// maxDeltaResult 是增量目标对象的长度上限。Git 不对超过 core.bigFileThreshold（默认512MB）
+// 的对象做增量，这里留出余量，拒绝头部声明了异常长度的增量
+const maxDeltaResult = 1 << 30
+
+// ApplyDelta 将Git格式的增量应用到基础对象上，返回目标对象内容。
+// 增量以基础对象长度和目标对象长度开头，之后是一系列指令：
+//
+//	1xxxxxxx 复制：从基础对象复制一段数据，低7位表示后面有哪些偏移和长度字节
+//	0xxxxxxx 插入：直接插入后面的 xxxxxxx 个字节
+func ApplyDelta(base, delta []byte) ([]byte, error) {
+	baseSize, n := readVarint(delta)
+	if n == 0 {
+		return nil, errDeltaTruncated
+	}
+	delta = delta[n:]
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("基础对象长度不匹配: 期望 %d，实际 %d", baseSize, len(base))
+	}
+
+	resultSize, n := readVarint(delta)
+	if n == 0 {
+		return nil, errDeltaTruncated
+	}
+	delta = delta[n:]
+	// 每条指令最多产生 0x10000 个字节，头部声明的长度不能超过指令所能产生的长度
+	if resultSize > maxDeltaResult || resultSize > uint64(len(delta))*0x10000 {
+		return nil, fmt.Errorf("无效的目标对象长度 %d", resultSize)
+	}
+
+	// 长度来自不可信的数据，只按实际内容的规模预分配，写入时逐步增长
+	result := make([]byte, 0, min(resultSize, uint64(len(base)+len(delta))))
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			var offset, size uint64
+			for i := uint(0); i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errDeltaTruncated
+				}
+				if i < 4 {
+					offset |= uint64(delta[0]) << (8 * i)
+				} else {
+					size |= uint64(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, fmt.Errorf("复制指令超出基础对象范围")
+			}
+			if uint64(len(result))+size > resultSize {
+				return nil, fmt.Errorf("目标对象超出声明的长度 %d", resultSize)
+			}
+			result = append(result, base[offset:offset+size]...)
+
+		case op != 0:
+			if int(op) > len(delta) {
+				return nil, errDeltaTruncated
+			}
+			if uint64(len(result))+uint64(op) > resultSize {
+				return nil, fmt.Errorf("目标对象超出声明的长度 %d", resultSize)
+			}
+			result = append(result, delta[:op]...)
+			delta = delta[op:]
+
+		default:
+			return nil, fmt.Errorf("无效的增量指令 0")
+		}
+	}
+
+	if uint64(len(result)) != resultSize {
+		return nil, fmt.Errorf("目标对象长度不匹配: 期望 %d，实际 %d", resultSize, len(result))
+	}
+	return result, nil
+}
+
+// readVarint 读取增量头部的变长整数（每字节7位，小端，最高位表示后面还有字节），
+// 返回数值和读取的字节数，数据不完整时字节数为0
+func readVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i, b := range data {
+		if i >= 10 {
+			return 0, 0
+		}
+		value |= uint64(b&0x7f) << (7 * uint(i))
+		if b&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// 建立基础对象索引时的块大小，目标对象中至少有这么长的相同数据才会生成复制指令
+const deltaBlockSize = 16
+
+// 单条复制指令的最大长度，更长的相同数据拆成多条指令（与Git的第2版包文件兼容）
+const maxCopySize = 0x10000
+
+// deltaIndex 记录基础对象中每个数据块第一次出现的位置，可以对多个目标对象重复使用
+type deltaIndex struct {
+	base   []byte
+	blocks map[string]int
+}
+
+func newDeltaIndex(base []byte) *deltaIndex {
+	index := &deltaIndex{
+		base:   base,
+		blocks: make(map[string]int, len(base)/deltaBlockSize),
+	}
+	for i := 0; i+deltaBlockSize <= len(base); i += deltaBlockSize {
+		key := string(base[i : i+deltaBlockSize])
+		if _, ok := index.blocks[key]; !ok {
+			index.blocks[key] = i
+		}
+	}
+	return index
+}
+
+// CreateDelta 计算从 base 还原 target 的增量，格式与 ApplyDelta 相同
+func CreateDelta(base, target []byte) []byte {
+	return newDeltaIndex(base).delta(target, 0)
+}
+
+// delta 逐字节扫描目标对象，在基础对象中找到相同的数据块时向前后扩展并生成复制指令，
+// 其余数据生成插入指令。maxSize 大于0且增量超过该长度时放弃，返回 nil。
+func (index *deltaIndex) delta(target []byte, maxSize int) []byte {
+	base := index.base
+	out := appendVarint(nil, uint64(len(base)))
+	out = appendVarint(out, uint64(len(target)))
+
+	literal := 0 // 尚未输出的插入数据的起点
+	for i := 0; i+deltaBlockSize <= len(target); {
+		offset, ok := index.blocks[string(target[i:i+deltaBlockSize])]
+		if !ok {
+			i++
+			continue
+		}
+
+		size := deltaBlockSize
+		for offset+size < len(base) && i+size < len(target) && base[offset+size] == target[i+size] {
+			size++
+		}
+		for i > literal && offset > 0 && base[offset-1] == target[i-1] {
+			i--
+			offset--
+			size++
+		}
+
+		out = appendInsert(out, target[literal:i])
+		out = appendCopy(out, offset, size)
+		i += size
+		literal = i
+
+		if maxSize > 0 && len(out) > maxSize {
+			return nil
+		}
+	}
+
+	out = appendInsert(out, target[literal:])
+	if maxSize > 0 && len(out) > maxSize {
+		return nil
+	}
+	return out
+}
+
+// appendInsert 输出插入指令，每条最多插入127个字节
+func appendInsert(out, data []byte) []byte {
+	for len(data) > 0 {
+		n := len(data)
+		if n > 0x7f {
+			n = 0x7f
+		}
+		out = append(out, byte(n))
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
+
+// appendCopy 输出复制指令：偏移和长度只写出非零的字节，并在指令字节的低7位中标记
+func appendCopy(out []byte, offset, size int) []byte {
+	for size > 0 {
+		n := size
+		if n > maxCopySize {
+			n = maxCopySize
+		}
+
+		op := byte(0x80)
+		var args []byte
+		for i := 0; i < 4; i++ {
+			if b := byte(offset >> (8 * i)); b != 0 {
+				op |= 1 << i
+				args = append(args, b)
+			}
+		}
+		// 长度为0x10000时省略长度字节
+		if n != maxCopySize {
+			for i := 0; i < 3; i++ {
+				if b := byte(n >> (8 * i)); b != 0 {
+					op |= 1 << (4 + i)
+					args = append(args, b)
+				}
+			}
+		}
+		out = append(out, op)
+		out = append(out, args...)
+
+		offset += n
+		size -= n
+	}
+	return out
+}
+
+// appendVarint 输出增量头部的变长整数，是 readVarint 的逆操作
+func appendVarint(out []byte, value uint64) []byte {
+	for value >= 0x80 {
+		out = append(out, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(out, byte(value))
+}
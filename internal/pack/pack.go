@@ -0,0 +1,296 @@
+// Package pack 读写Git的包文件（.pack）及其索引（.idx），支持增量对象的还原和生成
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 对象类型名，与对象头中的类型一致
+const (
+	TypeCommit = "commit"
+	TypeTree   = "tree"
+	TypeBlob   = "blob"
+	TypeTag    = "tag"
+)
+
+// 包文件中的对象类型编号
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+var typeNames = map[int]string{
+	objCommit: TypeCommit,
+	objTree:   TypeTree,
+	objBlob:   TypeBlob,
+	objTag:    TypeTag,
+}
+
+// 增量基础对象缓存的上限，超过后清空
+const maxCacheBytes = 64 << 20
+
+// Pack 是一个打开的包文件
+type Pack struct {
+	file    *os.File
+	size    int64
+	hashes  []string         // 按哈希排序，与索引中的顺序一致
+	offsets map[string]int64 // 哈希 -> 对象在包文件中的偏移
+
+	// ExternalBase 用于读取不在本包中的 REF_DELTA 基础对象，可以为 nil
+	ExternalBase func(hash string) (string, []byte, error)
+
+	cache      map[int64]*cachedObject
+	cacheBytes int
+}
+
+type cachedObject struct {
+	objType string
+	data    []byte
+}
+
+// Open 打开包文件，packPath 为 .pack 文件路径，对应的 .idx 文件必须存在
+func Open(packPath string) (*Pack, error) {
+	idxPath := strings.TrimSuffix(packPath, ".pack") + ".idx"
+	idx, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取包索引失败: %v", err)
+	}
+
+	hashes, offsets, err := parseIndex(idx)
+	if err != nil {
+		return nil, fmt.Errorf("解析包索引 %s 失败: %v", idxPath, err)
+	}
+
+	file, err := os.Open(packPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]byte, 12)
+	if _, err := file.ReadAt(header, 0); err != nil || string(header[:4]) != "PACK" {
+		file.Close()
+		return nil, fmt.Errorf("%s 不是包文件", packPath)
+	}
+	if version := binary.BigEndian.Uint32(header[4:8]); version != 2 && version != 3 {
+		file.Close()
+		return nil, fmt.Errorf("不支持的包文件版本 %d", version)
+	}
+
+	return &Pack{
+		file:    file,
+		size:    info.Size(),
+		hashes:  hashes,
+		offsets: offsets,
+		cache:   make(map[int64]*cachedObject),
+	}, nil
+}
+
+// Close 关闭包文件
+func (p *Pack) Close() error {
+	return p.file.Close()
+}
+
+// Path 返回包文件的路径
+func (p *Pack) Path() string {
+	return p.file.Name()
+}
+
+// Hashes 返回包中所有对象的哈希，按哈希排序
+func (p *Pack) Hashes() []string {
+	return p.hashes
+}
+
+// Contains 判断包中是否有指定对象
+func (p *Pack) Contains(hash string) bool {
+	_, ok := p.offsets[hash]
+	return ok
+}
+
+// Read 读取包中的对象，返回对象类型和还原后的内容
+func (p *Pack) Read(hash string) (string, []byte, error) {
+	offset, ok := p.offsets[hash]
+	if !ok {
+		return "", nil, fmt.Errorf("对象 %s 不在包中", hash)
+	}
+	return p.readAt(offset, 0)
+}
+
+// readAt 读取指定偏移处的对象，增量对象会递归还原其基础对象
+func (p *Pack) readAt(offset int64, depth int) (string, []byte, error) {
+	if cached, ok := p.cache[offset]; ok {
+		return cached.objType, cached.data, nil
+	}
+	if depth > 1000 {
+		return "", nil, fmt.Errorf("增量链过长")
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(p.file, offset, p.size-offset))
+	objType, _, err := readEntryHeader(reader)
+	if err != nil {
+		return "", nil, fmt.Errorf("读取偏移 %d 处的对象失败: %v", offset, err)
+	}
+
+	var baseType string
+	var base []byte
+	switch objType {
+	case objOfsDelta:
+		distance, err := readOffset(reader)
+		if err != nil {
+			return "", nil, err
+		}
+		if distance <= 0 || distance > offset {
+			return "", nil, fmt.Errorf("无效的增量基础偏移")
+		}
+		if baseType, base, err = p.readAt(offset-distance, depth+1); err != nil {
+			return "", nil, err
+		}
+	case objRefDelta:
+		raw := make([]byte, 20)
+		if _, err := io.ReadFull(reader, raw); err != nil {
+			return "", nil, err
+		}
+		baseHash := hex.EncodeToString(raw)
+		if baseOffset, ok := p.offsets[baseHash]; ok {
+			baseType, base, err = p.readAt(baseOffset, depth+1)
+		} else if p.ExternalBase != nil {
+			baseType, base, err = p.ExternalBase(baseHash)
+		} else {
+			err = fmt.Errorf("增量的基础对象 %s 不在包中", baseHash)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	default:
+		if _, ok := typeNames[objType]; !ok {
+			return "", nil, fmt.Errorf("未知的对象类型编号 %d", objType)
+		}
+	}
+
+	data, err := inflate(reader)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := typeNames[objType]
+	if base != nil {
+		name = baseType
+		if data, err = ApplyDelta(base, data); err != nil {
+			return "", nil, err
+		}
+	}
+
+	p.remember(offset, name, data)
+	return name, data, nil
+}
+
+// remember 缓存还原后的对象，增量链上的基础对象经常被重复读取
+func (p *Pack) remember(offset int64, objType string, data []byte) {
+	if p.cacheBytes+len(data) > maxCacheBytes {
+		p.cache = make(map[int64]*cachedObject)
+		p.cacheBytes = 0
+	}
+	p.cache[offset] = &cachedObject{objType: objType, data: data}
+	p.cacheBytes += len(data)
+}
+
+// readEntryHeader 读取对象头：类型编号和还原后的长度
+func readEntryHeader(r io.ByteReader) (int, uint64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	objType := int(b>>4) & 7
+	size := uint64(b & 0x0f)
+	for shift := uint(4); b&0x80 != 0; shift += 7 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= uint64(b&0x7f) << shift
+	}
+	return objType, size, nil
+}
+
+// readOffset 读取 OFS_DELTA 中基础对象的相对偏移
+func readOffset(r io.ByteReader) (int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	offset := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		offset = ((offset + 1) << 7) | int64(b&0x7f)
+	}
+	return offset, nil
+}
+
+// inflate 解压 zlib 数据
+func inflate(r io.Reader) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// parseIndex 解析第2版包索引：
+//
+//	\377tOc、版本号、256项扇出表、排好序的哈希、CRC32、32位偏移、64位大偏移
+func parseIndex(idx []byte) ([]string, map[string]int64, error) {
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte("\377tOc")) {
+		return nil, nil, fmt.Errorf("只支持第2版包索引")
+	}
+	if version := binary.BigEndian.Uint32(idx[4:8]); version != 2 {
+		return nil, nil, fmt.Errorf("不支持的包索引版本 %d", version)
+	}
+
+	count := int(binary.BigEndian.Uint32(idx[8+255*4:]))
+	namesStart := 8 + 256*4
+	offsetsStart := namesStart + count*20 + count*4
+	largeStart := offsetsStart + count*4
+	if len(idx) < largeStart {
+		return nil, nil, fmt.Errorf("包索引被截断")
+	}
+
+	hashes := make([]string, count)
+	offsets := make(map[string]int64, count)
+	for i := 0; i < count; i++ {
+		hash := hex.EncodeToString(idx[namesStart+i*20 : namesStart+(i+1)*20])
+		offset := int64(binary.BigEndian.Uint32(idx[offsetsStart+i*4:]))
+		if offset&0x80000000 != 0 {
+			pos := largeStart + int(offset&0x7fffffff)*8
+			if len(idx) < pos+8 {
+				return nil, nil, fmt.Errorf("包索引被截断")
+			}
+			offset = int64(binary.BigEndian.Uint64(idx[pos:]))
+		}
+		hashes[i] = hash
+		offsets[hash] = offset
+	}
+
+	if !sort.StringsAreSorted(hashes) {
+		return nil, nil, fmt.Errorf("包索引中的哈希没有排序")
+	}
+	return hashes, offsets, nil
+}
@@ -0,0 +1,149 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func objectHash(objType string, data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", objType, len(data))
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func TestCreateDeltaRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		data := make([]byte, n)
+		rng.Read(data)
+		return data
+	}
+	long := random(300000)
+
+	cases := []struct {
+		name         string
+		base, target []byte
+	}{
+		{"空对象", nil, nil},
+		{"空基础对象", nil, []byte("hello")},
+		{"空目标对象", []byte("hello"), nil},
+		{"相同内容", long, long},
+		{"插入和删除", long, append(append(append([]byte{}, long[:1000]...), random(500)...), long[5000:]...)},
+		{"完全不同", random(5000), random(5000)},
+		{"重复数据", bytes.Repeat([]byte("abcd"), 50000), bytes.Repeat([]byte("abce"), 50000)},
+	}
+	for _, c := range cases {
+		delta := CreateDelta(c.base, c.target)
+		result, err := ApplyDelta(c.base, delta)
+		if err != nil {
+			t.Fatalf("%s: 还原增量失败: %v", c.name, err)
+		}
+		if !bytes.Equal(result, c.target) {
+			t.Fatalf("%s: 还原的内容与目标不同", c.name)
+		}
+	}
+
+	// 只修改少量数据时增量应当远小于目标对象
+	edited := append([]byte{}, long...)
+	copy(edited[1000:], "edited")
+	if delta := CreateDelta(long, edited); len(delta) > 100 {
+		t.Fatalf("增量过大: %d 字节", len(delta))
+	}
+}
+
+func TestApplyDeltaRejectsMalformed(t *testing.T) {
+	base := []byte("0123456789")
+	cases := []struct {
+		name  string
+		delta []byte
+	}{
+		// 头部声明目标长度约为 2^62 字节，只有一条插入指令
+		{"声明长度过大", []byte{10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x3f, 1, 'a'}},
+		{"超过指令能产生的长度", []byte{10, 0x80, 0x80, 0x10, 1, 'a'}},
+		{"插入超出声明长度", []byte{10, 1, 2, 'a', 'b'}},
+		{"复制超出声明长度", []byte{10, 2, 0x90, 10}},
+		{"复制超出基础对象", []byte{10, 20, 0x90, 20}},
+		{"长度不足", []byte{10, 5, 2, 'a', 'b'}},
+		{"数据截断", []byte{10, 5, 3, 'a'}},
+	}
+	for _, c := range cases {
+		if _, err := ApplyDelta(base, c.delta); err == nil {
+			t.Errorf("%s: 应当拒绝无效的增量", c.name)
+		}
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一文件的多个版本，除第一个外都应以增量保存
+	var lines bytes.Buffer
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&lines, "line %d\n", i)
+	}
+	objects := map[string][]byte{}
+	var order []string
+	for i := 0; i < 5; i++ {
+		data := append([]byte(fmt.Sprintf("version %d\n", i)), lines.Bytes()...)
+		hash := objectHash(TypeBlob, data)
+		objects[hash] = data
+		order = append(order, hash)
+		if err := w.Add(hash, TypeBlob, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nmessage\n")
+	commitHash := objectHash(TypeCommit, commit)
+	if err := w.Add(commitHash, TypeCommit, commit); err != nil {
+		t.Fatal(err)
+	}
+
+	count, deltas := w.Stats()
+	if count != 6 || deltas != 4 {
+		t.Fatalf("期望6个对象、4个增量，实际 %d 个对象、%d 个增量", count, deltas)
+	}
+
+	packPath, err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Open(packPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if len(p.Hashes()) != 6 {
+		t.Fatalf("索引中有 %d 个对象，期望6个", len(p.Hashes()))
+	}
+	for _, hash := range order {
+		objType, data, err := p.Read(hash)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", hash, err)
+		}
+		if objType != TypeBlob || !bytes.Equal(data, objects[hash]) {
+			t.Fatalf("对象 %s 的内容不正确", hash)
+		}
+	}
+	if objType, data, err := p.Read(commitHash); err != nil || objType != TypeCommit || !bytes.Equal(data, commit) {
+		t.Fatalf("读取提交对象失败: %v", err)
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	w, err := NewWriter(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, err := w.Close(); err != nil || path != "" {
+		t.Fatalf("没有对象时不应生成包文件: %q %v", path, err)
+	}
+}
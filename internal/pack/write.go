@@ -0,0 +1,330 @@
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// 增量压缩的参数
+const (
+	deltaWindow   = 10       // 与最近写入的多少个同类对象比较
+	maxDeltaDepth = 50       // 增量链的最大长度，链越长读取越慢
+	minDeltaSize  = 64       // 更小的对象不做增量
+	maxDeltaBase  = 64 << 20 // 更大的对象不参与增量计算
+)
+
+var typeNumbers = map[string]int{
+	TypeCommit: objCommit,
+	TypeTree:   objTree,
+	TypeBlob:   objBlob,
+	TypeTag:    objTag,
+}
+
+// Writer 按顺序写入对象，生成第2版包文件和索引。每个对象会与最近写入的同类对象比较，
+// 增量明显更小时以 OFS_DELTA 保存，因此相似的对象（例如同一文件的不同版本）应该相邻写入。
+type Writer struct {
+	dir     string
+	file    *os.File
+	out     *bufio.Writer
+	offset  int64
+	entries []*indexEntry
+	seen    map[string]bool
+	window  []*windowObject
+	deltas  int
+}
+
+// indexEntry 是索引中的一项
+type indexEntry struct {
+	hash   []byte
+	offset int64
+	crc    uint32
+}
+
+// windowObject 是增量窗口中最近写入的对象
+type windowObject struct {
+	objType string
+	data    []byte
+	offset  int64
+	depth   int
+	index   *deltaIndex // 第一次作为基础对象时建立
+}
+
+// NewWriter 在 dir 中创建临时包文件，Close 后重命名为 pack-<校验和>.pack
+func NewWriter(dir string) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建包目录失败: %v", err)
+	}
+	file, err := os.CreateTemp(dir, "tmp_pack_")
+	if err != nil {
+		return nil, fmt.Errorf("创建包文件失败: %v", err)
+	}
+
+	w := &Writer{
+		dir:  dir,
+		file: file,
+		out:  bufio.NewWriter(file),
+		seen: make(map[string]bool),
+	}
+	// 对象数在 Close 时回填
+	header := []byte{'P', 'A', 'C', 'K', 0, 0, 0, 2, 0, 0, 0, 0}
+	if err := w.write(header); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Add 写入一个对象，重复的对象会被忽略
+func (w *Writer) Add(hash, objType string, data []byte) error {
+	if w.seen[hash] {
+		return nil
+	}
+	typeNumber, ok := typeNumbers[objType]
+	if !ok {
+		return fmt.Errorf("未知的对象类型 %q", objType)
+	}
+	rawHash, err := hex.DecodeString(hash)
+	if err != nil || len(rawHash) != 20 {
+		return fmt.Errorf("无效的对象哈希: %q", hash)
+	}
+
+	base, delta := w.findDelta(objType, data)
+
+	var entry bytes.Buffer
+	if base != nil {
+		entry.Write(appendEntryHeader(nil, objOfsDelta, uint64(len(delta))))
+		entry.Write(encodeOffset(w.offset - base.offset))
+		if err := deflate(&entry, delta); err != nil {
+			return err
+		}
+	} else {
+		entry.Write(appendEntryHeader(nil, typeNumber, uint64(len(data))))
+		if err := deflate(&entry, data); err != nil {
+			return err
+		}
+	}
+
+	object := &windowObject{objType: objType, data: data, offset: w.offset}
+	if base != nil {
+		object.depth = base.depth + 1
+		w.deltas++
+	}
+	w.entries = append(w.entries, &indexEntry{
+		hash:   rawHash,
+		offset: w.offset,
+		crc:    crc32.ChecksumIEEE(entry.Bytes()),
+	})
+	w.seen[hash] = true
+	if err := w.write(entry.Bytes()); err != nil {
+		return err
+	}
+
+	if len(data) <= maxDeltaBase {
+		w.window = append(w.window, object)
+		if len(w.window) > deltaWindow {
+			w.window = w.window[1:]
+		}
+	}
+	return nil
+}
+
+// findDelta 在窗口中寻找增量最小的基础对象，增量不到原始长度的一半时才使用
+func (w *Writer) findDelta(objType string, data []byte) (*windowObject, []byte) {
+	if len(data) < minDeltaSize || len(data) > maxDeltaBase {
+		return nil, nil
+	}
+
+	var best *windowObject
+	var bestDelta []byte
+	limit := len(data) / 2
+	for i := len(w.window) - 1; i >= 0; i-- {
+		base := w.window[i]
+		if base.objType != objType || base.depth >= maxDeltaDepth {
+			continue
+		}
+		// 目标比基础对象多出的部分只能用插入指令，超过上限时不必计算
+		if len(data)-len(base.data) > limit {
+			continue
+		}
+
+		if base.index == nil {
+			base.index = newDeltaIndex(base.data)
+		}
+		if delta := base.index.delta(data, limit); delta != nil {
+			best, bestDelta = base, delta
+			limit = len(delta) - 1
+		}
+	}
+	return best, bestDelta
+}
+
+// Stats 返回已写入的对象数和其中以增量保存的对象数
+func (w *Writer) Stats() (objects, deltas int) {
+	return len(w.entries), w.deltas
+}
+
+// Close 回填对象数，写入校验和及索引，返回包文件路径。没有写入任何对象时不生成文件，返回空字符串。
+func (w *Writer) Close() (string, error) {
+	if len(w.entries) == 0 {
+		w.Abort()
+		return "", nil
+	}
+	if err := w.out.Flush(); err != nil {
+		w.Abort()
+		return "", fmt.Errorf("写入包文件失败: %v", err)
+	}
+
+	checksum, err := w.finishPack()
+	if err != nil {
+		w.Abort()
+		return "", err
+	}
+
+	name := filepath.Join(w.dir, "pack-"+hex.EncodeToString(checksum))
+	idxFile, err := os.CreateTemp(w.dir, "tmp_idx_")
+	if err != nil {
+		w.Abort()
+		return "", fmt.Errorf("创建包索引失败: %v", err)
+	}
+	_, err = idxFile.Write(w.buildIndex(checksum))
+	if closeErr := idxFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = w.file.Close()
+	}
+	// 先放置索引再放置包文件，读取方只会看到带有索引的包文件
+	if err == nil {
+		err = os.Rename(idxFile.Name(), name+".idx")
+	}
+	if err == nil {
+		err = os.Rename(w.file.Name(), name+".pack")
+	}
+	if err != nil {
+		os.Remove(idxFile.Name())
+		w.Abort()
+		return "", fmt.Errorf("写入包文件失败: %v", err)
+	}
+	return name + ".pack", nil
+}
+
+// Abort 放弃写入并删除临时文件
+func (w *Writer) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// finishPack 回填对象数并在文件末尾追加全部内容的SHA-1校验和
+func (w *Writer) finishPack() ([]byte, error) {
+	count := make([]byte, 4)
+	binary.BigEndian.PutUint32(count, uint32(len(w.entries)))
+	if _, err := w.file.WriteAt(count, 8); err != nil {
+		return nil, fmt.Errorf("写入包文件失败: %v", err)
+	}
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(w.file, 0, w.offset)); err != nil {
+		return nil, fmt.Errorf("计算包文件校验和失败: %v", err)
+	}
+	checksum := hash.Sum(nil)
+	if _, err := w.file.WriteAt(checksum, w.offset); err != nil {
+		return nil, fmt.Errorf("写入包文件失败: %v", err)
+	}
+	return checksum, nil
+}
+
+// buildIndex 生成第2版包索引，格式见 parseIndex
+func (w *Writer) buildIndex(packChecksum []byte) []byte {
+	entries := make([]*indexEntry, len(w.entries))
+	copy(entries, w.entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].hash, entries[j].hash) < 0
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("\377tOc")
+	binary.Write(&buf, binary.BigEndian, uint32(2))
+
+	var fanout [256]uint32
+	for _, entry := range entries {
+		fanout[entry.hash[0]]++
+	}
+	for i := 1; i < 256; i++ {
+		fanout[i] += fanout[i-1]
+	}
+	binary.Write(&buf, binary.BigEndian, fanout)
+
+	for _, entry := range entries {
+		buf.Write(entry.hash)
+	}
+	for _, entry := range entries {
+		binary.Write(&buf, binary.BigEndian, entry.crc)
+	}
+
+	// 超过31位的偏移保存在后面的64位偏移表中
+	var large []int64
+	for _, entry := range entries {
+		if entry.offset < 0x80000000 {
+			binary.Write(&buf, binary.BigEndian, uint32(entry.offset))
+			continue
+		}
+		binary.Write(&buf, binary.BigEndian, uint32(0x80000000|len(large)))
+		large = append(large, entry.offset)
+	}
+	for _, offset := range large {
+		binary.Write(&buf, binary.BigEndian, uint64(offset))
+	}
+
+	buf.Write(packChecksum)
+	checksum := sha1.Sum(buf.Bytes())
+	buf.Write(checksum[:])
+	return buf.Bytes()
+}
+
+func (w *Writer) write(data []byte) error {
+	if _, err := w.out.Write(data); err != nil {
+		return fmt.Errorf("写入包文件失败: %v", err)
+	}
+	w.offset += int64(len(data))
+	return nil
+}
+
+// appendEntryHeader 输出对象头，是 readEntryHeader 的逆操作
+func appendEntryHeader(out []byte, objType int, size uint64) []byte {
+	b := byte(objType<<4) | byte(size&0x0f)
+	for size >>= 4; size > 0; size >>= 7 {
+		out = append(out, b|0x80)
+		b = byte(size & 0x7f)
+	}
+	return append(out, b)
+}
+
+// encodeOffset 输出 OFS_DELTA 中基础对象的相对偏移，是 readOffset 的逆操作
+func encodeOffset(offset int64) []byte {
+	out := []byte{byte(offset & 0x7f)}
+	for offset >>= 7; offset > 0; offset >>= 7 {
+		offset--
+		out = append([]byte{byte(0x80 | offset&0x7f)}, out...)
+	}
+	return out
+}
+
+// deflate 将数据以 zlib 压缩后写入 buf
+func deflate(buf *bytes.Buffer, data []byte) error {
+	zw := zlib.NewWriter(buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("压缩对象失败: %v", err)
+	}
+	return nil
+}
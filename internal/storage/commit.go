@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encode 返回提交的规范序列化形式，提交ID即为以此为内容的提交对象哈希：
+//
+//	tree <树哈希>
+//	parent <父提交ID>（合并提交有多行）
+//	author <作者> <Unix时间> <时区>
+//	committer <提交者> <Unix时间> <时区>
+//
+//	<提交信息>
+//
+// 提交自身的ID不参与序列化，因此相同内容总能得到相同的ID。
+func (c *Commit) Encode() []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "tree %s\n", c.TreeHash)
+	for _, parent := range c.Parents {
+		fmt.Fprintf(&buf, "parent %s\n", parent)
+	}
+
+	committer := c.Committer
+	if committer == "" {
+		committer = c.Author
+	}
+	fmt.Fprintf(&buf, "author %s %s\n", c.Author, FormatSignatureTime(c.Timestamp))
+	fmt.Fprintf(&buf, "committer %s %s\n", committer, FormatSignatureTime(c.CommitTime()))
+
+	buf.WriteString("\n")
+	buf.WriteString(c.Message)
+	if !strings.HasSuffix(c.Message, "\n") {
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// FirstParent 返回第一个父提交ID，根提交返回空字符串
+func (c *Commit) FirstParent() string {
+	if len(c.Parents) == 0 {
+		return ""
+	}
+	return c.Parents[0]
+}
+
+// IsMerge 判断是否为合并提交
+func (c *Commit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// CommitTime 返回提交时间，旧版本的提交没有单独的提交时间时使用作者时间
+func (c *Commit) CommitTime() time.Time {
+	if c.CommitTimestamp.IsZero() {
+		return c.Timestamp
+	}
+	return c.CommitTimestamp
+}
+
+// decodeCommit 解析规范序列化的提交
+func decodeCommit(id string, data []byte) (*Commit, error) {
+	// 兼容旧版本以JSON格式保存的提交
+	if bytes.HasPrefix(data, []byte("{")) {
+		var legacy struct {
+			Commit
+			ParentID string `json:"parent_id"`
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return nil, err
+		}
+		commit := legacy.Commit
+		if legacy.ParentID != "" {
+			commit.Parents = []string{legacy.ParentID}
+		}
+		commit.ID = id
+		return &commit, nil
+	}
+
+	header, message, ok := strings.Cut(string(data), "\n\n")
+	if !ok {
+		return nil, fmt.Errorf("缺少提交信息")
+	}
+
+	commit := &Commit{
+		ID:      id,
+		Message: strings.TrimSuffix(message, "\n"),
+	}
+
+	for _, line := range strings.Split(header, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		var err error
+		switch key {
+		case "tree":
+			commit.TreeHash = value
+		case "parent":
+			commit.Parents = append(commit.Parents, value)
+		case "author":
+			commit.Author, commit.Timestamp, err = ParseSignature(value)
+		case "committer":
+			commit.Committer, commit.CommitTimestamp, err = ParseSignature(value)
+		default:
+			// 忽略Git写入的 encoding、gpgsig、mergetag 等字段及其续行
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return commit, nil
+}
+
+// FormatSignatureTime 将时间格式化为 "<Unix时间> <±hhmm>"
+func FormatSignatureTime(t time.Time) string {
+	return fmt.Sprintf("%d %s", t.Unix(), t.Format("-0700"))
+}
+
+// ParseSignature 解析 "<身份> <Unix时间> <±hhmm>"
+func ParseSignature(value string) (string, time.Time, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 3 {
+		return "", time.Time{}, fmt.Errorf("无效的签名: %q", value)
+	}
+
+	seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("无效的时间戳: %q", value)
+	}
+
+	zone, err := time.Parse("-0700", fields[len(fields)-1])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("无效的时区: %q", value)
+	}
+
+	ident := strings.Join(fields[:len(fields)-2], " ")
+	return ident, time.Unix(seconds, 0).In(zone.Location()), nil
+}
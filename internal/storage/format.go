@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 仓库格式
+const (
+	FormatCit = "cit" // cit 自己的格式，树对象为文本
+	FormatGit = "git" // 与Git逐字节兼容的格式，可以直接用Git工具打开
+)
+
+// Git格式中目录条目的模式没有前导0
+const gitModeTree = "40000"
+
+// gitConfig 是Git仓库的最小配置，让Git识别仓库格式
+const gitConfig = `[core]
+	repositoryformatversion = 0
+	filemode = true
+	bare = false
+`
+
+// citConfigSection 是 .git/config 中标记 cit 仓库的配置节，由 cit init --format=git 写入，
+// 也可以在Git创建的仓库中运行 git config cit.repository true 手动加入
+const citConfigSection = "[cit]\n\trepository = true\n"
+
+// IsCitGitDir 判断 .git 目录是否标记为可以由 cit 使用
+func IsCitGitDir(gitDir string) bool {
+	data, err := os.ReadFile(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "[cit]") {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkCitGitDir 在Git格式仓库的配置中加入 cit 标记，已经标记过或是 cit 格式的仓库时不修改
+func (s *Storage) MarkCitGitDir() error {
+	if s.format != FormatGit || IsCitGitDir(s.basePath) {
+		return nil
+	}
+	configPath := filepath.Join(s.basePath, "config")
+	file, err := os.OpenFile(configPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(citConfigSection)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Format 返回仓库格式
+func (s *Storage) Format() string {
+	return s.format
+}
+
+// writeGitConfig 在仓库中还没有配置文件时写入最小的Git配置
+func (s *Storage) writeGitConfig() error {
+	configPath := filepath.Join(s.basePath, "config")
+	if _, err := os.Stat(configPath); err == nil {
+		return nil
+	}
+	return os.WriteFile(configPath, []byte(gitConfig), 0644)
+}
+
+// encodeGitTree 将树序列化为Git的二进制格式，每个条目为：
+// <mode> <name>\0<20字节哈希>
+func encodeGitTree(tree *Tree) ([]byte, error) {
+	entries := make([]*TreeEntry, len(tree.Entries))
+	copy(entries, tree.Entries)
+	sortTreeEntries(entries)
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		hash, err := hex.DecodeString(entry.Hash)
+		if err != nil || len(hash) != 20 {
+			return nil, fmt.Errorf("无效的对象哈希: %q", entry.Hash)
+		}
+
+		mode := entry.Mode
+		if entry.IsTree() {
+			mode = gitModeTree
+		}
+		fmt.Fprintf(&buf, "%s %s\x00", mode, entry.Name)
+		buf.Write(hash)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeGitTree 解析Git二进制格式的树对象
+func decodeGitTree(data []byte) (*Tree, error) {
+	tree := &Tree{}
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok || len(rest) < 20 {
+			return nil, fmt.Errorf("树对象被截断")
+		}
+		mode, name, ok := strings.Cut(string(header), " ")
+		if !ok {
+			return nil, fmt.Errorf("无效的树条目: %q", header)
+		}
+		if err := CheckEntryName(name); err != nil {
+			return nil, err
+		}
+
+		entry := &TreeEntry{
+			Mode: mode,
+			Type: TypeBlob,
+			Hash: hex.EncodeToString(rest[:20]),
+			Name: name,
+		}
+		switch mode {
+		case gitModeTree, ModeTree:
+			entry.Mode, entry.Type = ModeTree, TypeTree
+		case ModeSubmodule:
+			entry.Type = TypeCommit
+		}
+
+		tree.Entries = append(tree.Entries, entry)
+		data = rest[20:]
+	}
+	return tree, nil
+}
@@ -0,0 +1,369 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cit/internal/pack"
+)
+
+// GCResult 记录一次垃圾回收的结果
+type GCResult struct {
+	Packed int    // 写入新包文件的对象数
+	Deltas int    // 其中以增量形式保存的对象数
+	Pruned int    // 删除的不可达对象数
+	Kept   int    // 不可达但仍在宽限期内、保留为松散对象的对象数
+	Pack   string // 新包文件的路径，没有可达对象时为空
+}
+
+// gcObject 是一个可达对象，path 是它在树中的路径，用于把同一文件的不同版本排在一起以便增量压缩
+type gcObject struct {
+	hash    string
+	objType string
+	path    string
+}
+
+// 对象在包文件中的顺序：提交、树、文件、标签
+var gcTypeOrder = map[string]int{TypeCommit: 0, TypeTree: 1, TypeBlob: 2, TypeTag: 3}
+
+// GC 将所有可达对象写入一个新的包文件，删除旧的包文件和已经打包的松散对象，
+// 并删除修改时间早于 expire 的不可达对象。较新的不可达对象可能属于正在进行的操作，
+// 保留为松散对象（旧包文件中的这类对象会被解包），留给以后的垃圾回收处理。
+func (s *Storage) GC(expire time.Time) (*GCResult, error) {
+	roots, reflogRoots, err := s.objectRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	walk := &objectWalk{storage: s, seen: make(map[string]bool)}
+	for _, root := range roots {
+		if err := walk.root(root, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, root := range reflogRoots {
+		if err := walk.root(root, true); err != nil {
+			return nil, err
+		}
+	}
+
+	// 同一路径的对象按发现顺序（从新到旧）相邻，较旧的版本以较新的版本为基础做增量
+	objects := walk.objects
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].objType != objects[j].objType {
+			return gcTypeOrder[objects[i].objType] < gcTypeOrder[objects[j].objType]
+		}
+		return objects[i].path < objects[j].path
+	})
+
+	result := &GCResult{}
+	if result.Pack, err = s.writePack(objects, result); err != nil {
+		return nil, err
+	}
+	if err := s.replacePacks(result.Pack, walk.seen, expire, result); err != nil {
+		return nil, err
+	}
+	if err := s.pruneLooseObjects(expire, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// objectRoots 返回仓库中直接记录的所有对象哈希：引用、packed-refs、分离的 HEAD、
+// 暂存区和冲突记录以及合并状态，另外单独返回引用日志中的新旧值
+func (s *Storage) objectRoots() (roots, reflogRoots []string, err error) {
+	refs, err := s.listRefs("refs")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range refs {
+		roots = append(roots, id)
+	}
+
+	packed, err := s.PackedRefs()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range packed {
+		roots = append(roots, id)
+	}
+
+	if branch, commitID, err := s.ReadHead(); err == nil && branch == "" && commitID != "" {
+		roots = append(roots, commitID)
+	}
+
+	err = filepath.WalkDir(filepath.Join(s.basePath, "logs"), func(logPath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		file, err := os.Open(logPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if fields := strings.SplitN(scanner.Text(), " ", 3); len(fields) == 3 {
+				reflogRoots = append(reflogRoots, fields[0], fields[1])
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	index, err := s.loadStaging()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, hash := range index.Entries {
+		roots = append(roots, hash)
+	}
+	for _, conflict := range index.Conflicts {
+		roots = append(roots, conflict.Base, conflict.Ours, conflict.Theirs)
+	}
+
+	for _, name := range []string{"MERGE_HEAD", "ORIG_HEAD"} {
+		if id, err := readRef(filepath.Join(s.basePath, name)); err == nil {
+			roots = append(roots, id)
+		}
+	}
+	return roots, reflogRoots, nil
+}
+
+// objectWalk 从根对象出发遍历所有可达对象
+type objectWalk struct {
+	storage *Storage
+	seen    map[string]bool
+	objects []*gcObject
+}
+
+// add 记录对象，已经记录过时返回 false
+func (w *objectWalk) add(hash, objType, objPath string) bool {
+	if w.seen[hash] {
+		return false
+	}
+	w.seen[hash] = true
+	w.objects = append(w.objects, &gcObject{hash: hash, objType: objType, path: objPath})
+	return true
+}
+
+// root 从一个根对象开始遍历。只有引用日志中的对象（optional 为 true）可能已经被删除，
+// 这样的根对象被忽略；引用等其他记录指向的对象缺失或损坏时返回错误，以免删除仍需要的对象。
+func (w *objectWalk) root(hash string, optional bool) error {
+	if hash == "" || hash == ZeroID || w.seen[hash] {
+		return nil
+	}
+	objType, err := w.storage.ObjectType(hash)
+	if err != nil {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("无法读取对象 %s: %v", hash, err)
+	}
+
+	switch objType {
+	case TypeCommit:
+		return w.commits(hash)
+	case TypeTree:
+		return w.tree(hash, "")
+	case TypeTag:
+		tag, err := w.storage.GetTag(hash)
+		if err != nil {
+			return err
+		}
+		w.add(hash, TypeTag, "")
+		return w.root(tag.Object, optional)
+	}
+	w.add(hash, objType, "")
+	return nil
+}
+
+// commits 遍历提交及其全部祖先。历史可能很长，因此用显式的栈，并优先沿第一个父提交遍历。
+func (w *objectWalk) commits(hash string) error {
+	stack := []string{hash}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !w.add(id, TypeCommit, "") {
+			continue
+		}
+
+		commit, err := w.storage.GetCommit(id)
+		if err != nil {
+			return err
+		}
+		if err := w.tree(commit.TreeHash, ""); err != nil {
+			return err
+		}
+		for i := len(commit.Parents) - 1; i >= 0; i-- {
+			stack = append(stack, commit.Parents[i])
+		}
+	}
+	return nil
+}
+
+// tree 遍历树对象，dir 是树在仓库中的路径
+func (w *objectWalk) tree(hash, dir string) error {
+	if !w.add(hash, TypeTree, dir) {
+		return nil
+	}
+
+	tree, err := w.storage.GetTree(hash)
+	if err != nil {
+		return err
+	}
+	for _, entry := range tree.Entries {
+		entryPath := path.Join(dir, entry.Name)
+		switch {
+		case entry.Mode == ModeSubmodule:
+			// 子模块指向其他仓库中的提交
+		case entry.IsTree():
+			if err := w.tree(entry.Hash, entryPath); err != nil {
+				return err
+			}
+		default:
+			w.add(entry.Hash, TypeBlob, entryPath)
+		}
+	}
+	return nil
+}
+
+// writePack 按顺序将对象写入 objects/pack 下的新包文件，返回包文件路径
+func (s *Storage) writePack(objects []*gcObject, result *GCResult) (string, error) {
+	w, err := pack.NewWriter(s.packDir())
+	if err != nil {
+		return "", err
+	}
+	for _, object := range objects {
+		_, data, err := s.ReadObject(object.hash)
+		if err == nil {
+			err = w.Add(object.hash, object.objType, data)
+		}
+		if err != nil {
+			w.Abort()
+			return "", err
+		}
+	}
+
+	result.Packed, result.Deltas = w.Stats()
+	return w.Close()
+}
+
+// replacePacks 删除新包文件以外的所有包文件。旧包文件中的不可达对象在包文件比 expire 新时
+// 解包为松散对象，修改时间沿用包文件的时间，由 pruneLooseObjects 按宽限期处理。
+func (s *Storage) replacePacks(newPack string, reachable map[string]bool, expire time.Time, result *GCResult) error {
+	packs, err := s.loadPacks()
+	if err != nil {
+		return err
+	}
+
+	dropped := make(map[string]bool)
+	for _, p := range packs {
+		if p.Path() == newPack {
+			continue
+		}
+		info, err := os.Stat(p.Path())
+		if err != nil {
+			return err
+		}
+		for _, hash := range p.Hashes() {
+			if reachable[hash] || dropped[hash] {
+				continue
+			}
+			objPath := s.objectPath(hash)
+			if _, err := os.Stat(objPath); err == nil {
+				continue
+			}
+			if info.ModTime().Before(expire) {
+				dropped[hash] = true
+				continue
+			}
+
+			objType, data, err := p.Read(hash)
+			if err != nil {
+				return err
+			}
+			if err := s.writeLooseObject(hash, objType, data); err != nil {
+				return err
+			}
+			if err := os.Chtimes(objPath, info.ModTime(), info.ModTime()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range packs {
+		p.Close()
+	}
+	s.packs = nil
+	for _, p := range packs {
+		if p.Path() == newPack {
+			continue
+		}
+		base := strings.TrimSuffix(p.Path(), ".pack")
+		for _, ext := range []string{".pack", ".idx", ".rev", ".bitmap"} {
+			if err := os.Remove(base + ext); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	result.Pruned += len(dropped)
+	return nil
+}
+
+// pruneLooseObjects 删除已经打包的松散对象，以及修改时间早于 expire 的不可达松散对象
+func (s *Storage) pruneLooseObjects(expire time.Time, result *GCResult) error {
+	root := filepath.Join(s.basePath, "objects")
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		dirPath := filepath.Join(root, dir.Name())
+		entries, err := os.ReadDir(dirPath)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			hash := dir.Name() + entry.Name()
+			if !objectHashPattern.MatchString(hash) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case s.isPacked(hash):
+			case info.ModTime().Before(expire):
+				result.Pruned++
+			default:
+				result.Kept++
+				continue
+			}
+			if err := os.Remove(filepath.Join(dirPath, entry.Name())); err != nil {
+				return err
+			}
+		}
+		// 目录不为空时删除失败，忽略即可
+		os.Remove(dirPath)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGCPacksReachableAndPrunesExpired(t *testing.T) {
+	s, err := NewStorage(t.TempDir(), FormatCit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := s.StoreBlob([]byte("reachable\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeHash, err := s.StoreTree(&Tree{Entries: []*TreeEntry{{Mode: ModeFile, Type: TypeBlob, Hash: blob, Name: "a.txt"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := &Commit{TreeHash: treeHash, Author: "a <a@b>", Timestamp: time.Unix(1, 0).UTC(), Message: "first"}
+	if err := s.StoreCommit(commit); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateBranch(&Branch{Name: "main", Head: commit.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, _ := s.StoreBlob([]byte("expired\n"))
+	recent, _ := s.StoreBlob([]byte("recent\n"))
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(s.objectPath(expired), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.GC(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Packed != 3 || result.Pruned != 1 || result.Kept != 1 {
+		t.Fatalf("期望打包3个、删除1个、保留1个对象，实际 %+v", result)
+	}
+
+	for _, hash := range []string{blob, treeHash, commit.ID} {
+		if _, err := os.Stat(s.objectPath(hash)); !os.IsNotExist(err) {
+			t.Fatalf("已打包的松散对象 %s 没有被删除", hash)
+		}
+		if _, _, err := s.ReadObject(hash); err != nil {
+			t.Fatalf("从包文件读取 %s 失败: %v", hash, err)
+		}
+	}
+	if _, _, err := s.ReadObject(expired); !os.IsNotExist(err) {
+		t.Fatalf("过期的不可达对象应被删除: %v", err)
+	}
+	if _, _, err := s.ReadObject(recent); err != nil {
+		t.Fatalf("宽限期内的不可达对象应被保留: %v", err)
+	}
+
+	// 对象的松散目录已不存在，完整哈希和缩写哈希都要在包文件中找到
+	for _, prefix := range []string{commit.ID, commit.ID[:7]} {
+		hashes, err := s.FindObjects(prefix)
+		if err != nil || len(hashes) != 1 || hashes[0] != commit.ID {
+			t.Fatalf("FindObjects(%s) = %v, %v", prefix, hashes, err)
+		}
+	}
+
+	// 再次回收时包文件被替换为新的包文件
+	if _, err := s.GC(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	packs, _ := filepath.Glob(filepath.Join(s.packDir(), "*.pack"))
+	if len(packs) != 1 {
+		t.Fatalf("期望1个包文件，实际 %d 个", len(packs))
+	}
+}
+
+func TestGCRefusesMissingRefTarget(t *testing.T) {
+	s, err := NewStorage(t.TempDir(), FormatCit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := s.StoreBlob([]byte("kept\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(s.objectPath(blob), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// 引用日志中的对象可以已经不存在
+	const missing = "1234567890123456789012345678901234567890"
+	entry := &ReflogEntry{Old: ZeroID, New: missing, Identity: "a <a@b>", Timestamp: time.Unix(1, 0), Message: "gone"}
+	if err := s.AppendReflog(BranchRef("old"), entry); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GC(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("引用日志中缺失的对象不应导致回收失败: %v", err)
+	}
+
+	// 分支指向缺失的对象时拒绝回收，不删除任何对象
+	blob, _ = s.StoreBlob([]byte("kept\n"))
+	if err := os.Chtimes(s.objectPath(blob), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.writeRef(s.branchPath("broken"), missing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GC(time.Now().Add(-time.Hour)); err == nil {
+		t.Fatalf("分支指向缺失的对象时回收应当失败")
+	}
+	if _, err := os.Stat(s.objectPath(blob)); err != nil {
+		t.Fatalf("回收失败时不应删除对象: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeState 记录进行中的合并，对应仓库目录中的 MERGE_HEAD、ORIG_HEAD 和 MERGE_MSG 文件
+type MergeState struct {
+	MergeHead string // 被合并进来的提交
+	OrigHead  string // 合并开始前当前分支指向的提交
+	Message   string // 合并提交的默认信息
+}
+
+var mergeStateFiles = []string{"MERGE_HEAD", "ORIG_HEAD", "MERGE_MSG"}
+
+// SaveMergeState 保存合并状态
+func (s *Storage) SaveMergeState(state *MergeState) error {
+	values := []string{state.MergeHead, state.OrigHead, state.Message}
+	for i, name := range mergeStateFiles {
+		if err := os.WriteFile(filepath.Join(s.basePath, name), []byte(values[i]+"\n"), 0644); err != nil {
+			return fmt.Errorf("保存合并状态失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetMergeState 读取合并状态，没有进行中的合并时返回 nil
+func (s *Storage) GetMergeState() (*MergeState, error) {
+	data, err := os.ReadFile(filepath.Join(s.basePath, "MERGE_HEAD"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取合并状态失败: %v", err)
+	}
+
+	state := &MergeState{MergeHead: strings.TrimSpace(string(data))}
+	if data, err := os.ReadFile(filepath.Join(s.basePath, "ORIG_HEAD")); err == nil {
+		state.OrigHead = strings.TrimSpace(string(data))
+	}
+	if data, err := os.ReadFile(filepath.Join(s.basePath, "MERGE_MSG")); err == nil {
+		state.Message = strings.TrimSuffix(string(data), "\n")
+	}
+	return state, nil
+}
+
+// ClearMergeState 清除合并状态，与Git一样保留 ORIG_HEAD
+func (s *Storage) ClearMergeState() error {
+	for _, name := range []string{"MERGE_HEAD", "MERGE_MSG"} {
+		if err := os.Remove(filepath.Join(s.basePath, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("清除合并状态失败: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,450 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 对象格式版本文件。版本1的对象是未压缩的原始内容，哈希只对内容计算，不记录类型
+const (
+	objectFormatFile    = "object-format"
+	objectFormatVersion = "2"
+)
+
+// migrateObjects 将旧格式的对象改写为带类型的压缩对象。对象哈希会随之改变，
+// 因此树、提交和标签中引用的哈希以及分支、标签、HEAD、引用日志、暂存区和合并状态
+// 都会被改写为新哈希。迁移完成后写入格式版本文件，之后打开仓库不再检查。
+func (s *Storage) migrateObjects() error {
+	formatPath := filepath.Join(s.basePath, objectFormatFile)
+	if isRegularFile(formatPath) {
+		return nil
+	}
+
+	legacy, err := s.legacyObjects()
+	if err != nil {
+		return err
+	}
+
+	if len(legacy) > 0 {
+		m := &objectMigration{storage: s, legacy: legacy, converted: make(map[string]string)}
+		if err := m.convertAll(); err != nil {
+			return err
+		}
+		if err := m.rewriteReferences(); err != nil {
+			return err
+		}
+
+		// 所有引用都改写之后才删除旧对象，中途失败时可以重新迁移
+		objectsDir := filepath.Join(s.basePath, "objects")
+		for hash := range legacy {
+			if m.converted[hash] == hash {
+				continue
+			}
+			path := s.objectPath(hash)
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			s.pruneEmptyDirs(filepath.Dir(path), objectsDir)
+		}
+	}
+
+	return writeFileAtomic(formatPath, objectFormatVersion+"\n")
+}
+
+// legacyObjects 找出对象目录中所有旧格式的对象，返回 哈希 -> 原始内容
+func (s *Storage) legacyObjects() (map[string][]byte, error) {
+	root := filepath.Join(s.basePath, "objects")
+	legacy := make(map[string][]byte)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		hash := strings.ReplaceAll(filepath.ToSlash(rel), "/", "")
+		if !objectHashPattern.MatchString(hash) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if objType, content, err := decodeLooseObject(bytes.NewReader(data)); err == nil && HashObject(objType, content) == hash {
+			return nil
+		}
+		legacy[hash] = data
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return legacy, nil
+}
+
+// objectMigration 记录对象迁移过程中旧哈希到新哈希的映射
+type objectMigration struct {
+	storage   *Storage
+	legacy    map[string][]byte
+	converted map[string]string
+}
+
+// legacyRoot 是仓库中直接记录的一个旧对象及其类型
+type legacyRoot struct {
+	hash    string
+	objType string
+}
+
+// convertAll 迁移全部旧对象。旧对象没有记录类型，因此先从引用、HEAD、引用日志、
+// 合并状态和暂存区出发，按引用关系确定可达对象的类型；只有没有被任何记录引用的对象
+// 才根据内容推断类型，其中像提交和标签的先迁移，使它们引用的树和文件对象仍按引用关系确定类型。
+func (m *objectMigration) convertAll() error {
+	roots, err := m.roots()
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		if _, err := m.convert(root.hash, root.objType); err != nil {
+			return err
+		}
+	}
+
+	hashes := make([]string, 0, len(m.legacy))
+	for hash := range m.legacy {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	for _, pass := range []func(objType string) bool{
+		func(objType string) bool { return objType == TypeCommit || objType == TypeTag },
+		func(objType string) bool { return true },
+	} {
+		for _, hash := range hashes {
+			if _, ok := m.converted[hash]; ok {
+				continue
+			}
+			if objType := legacyObjectType(hash, m.legacy[hash]); pass(objType) {
+				if _, err := m.convert(hash, objType); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// roots 返回仓库中直接记录的对象：分支、分离的 HEAD、引用日志和合并状态指向提交，
+// 标签指向提交或标签对象，暂存区和冲突记录中的都是文件对象
+func (m *objectMigration) roots() ([]legacyRoot, error) {
+	s := m.storage
+	var roots []legacyRoot
+
+	for _, dir := range []string{headsDir, tagsDir} {
+		refs, err := s.listRefs(dir)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(refs))
+		for name := range refs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			objType := TypeCommit
+			if data, ok := m.legacy[refs[name]]; dir == tagsDir && ok && !isCommitData(data) {
+				if _, err := decodeTag(refs[name], data); err == nil {
+					objType = TypeTag
+				}
+			}
+			roots = append(roots, legacyRoot{refs[name], objType})
+		}
+	}
+
+	if branch, commitID, err := s.ReadHead(); err == nil && branch == "" && commitID != "" {
+		roots = append(roots, legacyRoot{commitID, TypeCommit})
+	}
+
+	for _, name := range []string{"MERGE_HEAD", "ORIG_HEAD"} {
+		if id, err := readRef(filepath.Join(s.basePath, name)); err == nil {
+			roots = append(roots, legacyRoot{id, TypeCommit})
+		}
+	}
+
+	err := filepath.WalkDir(filepath.Join(s.basePath, "logs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if fields := strings.SplitN(scanner.Text(), " ", 3); len(fields) == 3 {
+				roots = append(roots, legacyRoot{fields[0], TypeCommit}, legacyRoot{fields[1], TypeCommit})
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	index, err := s.loadStaging()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(index.Entries))
+	for filePath := range index.Entries {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	for _, filePath := range paths {
+		roots = append(roots, legacyRoot{index.Entries[filePath], TypeBlob})
+	}
+	for _, conflict := range index.Conflicts {
+		for _, hash := range []string{conflict.Base, conflict.Ours, conflict.Theirs} {
+			if hash != "" {
+				roots = append(roots, legacyRoot{hash, TypeBlob})
+			}
+		}
+	}
+	return roots, nil
+}
+
+// convert 将旧对象及其引用的对象按 objType 写为新格式，返回新哈希。
+// 找不到的对象保留原哈希，不中断迁移。
+func (m *objectMigration) convert(hash, objType string) (string, error) {
+	if newHash, ok := m.converted[hash]; ok {
+		return newHash, nil
+	}
+	data, ok := m.legacy[hash]
+	if !ok {
+		return hash, nil
+	}
+	if actual := fmt.Sprintf("%x", sha1.Sum(data)); actual != hash {
+		return "", fmt.Errorf("对象 %s 校验失败: 实际 %s", hash, actual)
+	}
+	var err error
+	switch objType {
+	case TypeCommit:
+		data, err = m.convertCommit(hash, data)
+	case TypeTag:
+		data, err = m.convertTag(hash, data)
+	case TypeTree:
+		data, err = m.convertTree(data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("迁移对象 %s 失败: %v", hash, err)
+	}
+
+	newHash, err := m.storage.WriteObject(objType, data)
+	if err != nil {
+		return "", err
+	}
+	m.converted[hash] = newHash
+	return newHash, nil
+}
+
+func (m *objectMigration) convertCommit(hash string, data []byte) ([]byte, error) {
+	commit, err := decodeCommit(hash, data)
+	if err != nil {
+		return nil, err
+	}
+	if commit.TreeHash, err = m.convert(commit.TreeHash, TypeTree); err != nil {
+		return nil, err
+	}
+	for i, parent := range commit.Parents {
+		if commit.Parents[i], err = m.convert(parent, TypeCommit); err != nil {
+			return nil, err
+		}
+	}
+	return commit.Encode(), nil
+}
+
+func (m *objectMigration) convertTag(hash string, data []byte) ([]byte, error) {
+	tag, err := decodeTag(hash, data)
+	if err != nil {
+		return nil, err
+	}
+	if tag.Object, err = m.convert(tag.Object, tag.ObjectType); err != nil {
+		return nil, err
+	}
+	return tag.Encode(), nil
+}
+
+func (m *objectMigration) convertTree(data []byte) ([]byte, error) {
+	tree, err := decodeTree(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range tree.Entries {
+		if entry.Hash, err = m.convert(entry.Hash, entry.Type); err != nil {
+			return nil, err
+		}
+	}
+	return encodeTree(tree), nil
+}
+
+// mapped 返回旧哈希对应的新哈希，不是旧对象时原样返回
+func (m *objectMigration) mapped(hash string) string {
+	if newHash, ok := m.converted[hash]; ok {
+		return newHash
+	}
+	return hash
+}
+
+// rewriteReferences 将仓库中所有记录对象哈希的地方改写为新哈希
+func (m *objectMigration) rewriteReferences() error {
+	s := m.storage
+
+	for _, dir := range []string{headsDir, tagsDir} {
+		refs, err := s.listRefs(dir)
+		if err != nil {
+			return err
+		}
+		for name, id := range refs {
+			if newID := m.mapped(id); newID != id {
+				if err := s.writeRef(s.refPath(dir, name), newID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if branch, commitID, err := s.ReadHead(); err == nil && branch == "" && commitID != "" {
+		if err := s.SetHeadDetached(m.mapped(commitID)); err != nil {
+			return err
+		}
+	}
+
+	if err := m.rewriteReflogs(); err != nil {
+		return err
+	}
+
+	index, err := s.loadStaging()
+	if err != nil {
+		return err
+	}
+	for filePath, hash := range index.Entries {
+		index.Entries[filePath] = m.mapped(hash)
+	}
+	for _, conflict := range index.Conflicts {
+		conflict.Base = m.mapped(conflict.Base)
+		conflict.Ours = m.mapped(conflict.Ours)
+		conflict.Theirs = m.mapped(conflict.Theirs)
+	}
+	if err := s.saveStaging(index); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"MERGE_HEAD", "ORIG_HEAD"} {
+		path := filepath.Join(s.basePath, name)
+		id, err := readRef(path)
+		if err != nil {
+			continue
+		}
+		if err := writeFileAtomic(path, m.mapped(id)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteReflogs 改写引用日志每条记录中的新旧提交ID
+func (m *objectMigration) rewriteReflogs() error {
+	root := filepath.Join(m.storage.basePath, "logs")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		var buf strings.Builder
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if fields := strings.SplitN(line, " ", 3); len(fields) == 3 {
+				line = m.mapped(fields[0]) + " " + m.mapped(fields[1]) + " " + fields[2]
+			}
+			buf.WriteString(line + "\n")
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return writeFileAtomic(path, buf.String())
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// legacyObjectType 推断没有被任何记录引用的旧对象的类型，
+// 依次尝试按提交、标签和树解析，都不符合时视为文件对象。
+func legacyObjectType(hash string, data []byte) string {
+	if _, err := decodeCommit(hash, data); err == nil && isCommitData(data) {
+		return TypeCommit
+	}
+	if _, err := decodeTag(hash, data); err == nil {
+		return TypeTag
+	}
+	if isTreeData(data) {
+		return TypeTree
+	}
+	return TypeBlob
+}
+
+// isCommitData 判断内容是否像提交对象：JSON格式的旧提交或以 tree 行开头的规范格式
+func isCommitData(data []byte) bool {
+	text := string(data)
+	return strings.HasPrefix(text, "tree ") || (strings.HasPrefix(text, "{") && strings.Contains(text, `"tree_hash"`))
+}
+
+// isTreeData 判断内容是否为格式正确的树对象
+func isTreeData(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	tree, err := decodeTree(data)
+	if err != nil || len(tree.Entries) == 0 {
+		return false
+	}
+
+	for _, entry := range tree.Entries {
+		switch {
+		case entry.Type == TypeTree && entry.Mode == ModeTree:
+		case entry.Type == TypeBlob && (entry.Mode == ModeFile || entry.Mode == ModeExecutable):
+		default:
+			return false
+		}
+		if !objectHashPattern.MatchString(entry.Hash) {
+			return false
+		}
+	}
+
+	// 序列化结果必须与原内容完全一致
+	return string(encodeTree(tree)) == string(data)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeLegacyObject 按旧格式保存对象：未压缩的原始内容，哈希只对内容计算
+func writeLegacyObject(t *testing.T, dir, content string) string {
+	t.Helper()
+	hash := fmt.Sprintf("%x", sha1.Sum([]byte(content)))
+	path := filepath.Join(dir, "objects", hash[:2], hash[2:])
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestMigrateTypesObjectsByReachability(t *testing.T) {
+	dir := t.TempDir()
+
+	blob := writeLegacyObject(t, dir, "hello\n")
+	tree := writeLegacyObject(t, dir, fmt.Sprintf("100644 blob %s\tp.txt\n", blob))
+	commit := writeLegacyObject(t, dir, fmt.Sprintf("tree %s\nauthor a <a@b> 1 +0000\ncommitter a <a@b> 1 +0000\n\nfirst\n", tree))
+	// 暂存的文件内容恰好像一个提交，必须仍按文件对象迁移
+	staged := fmt.Sprintf("tree %s\nauthor a <a@b> 2 +0000\n\nnot a commit\n", tree)
+	stagedHash := writeLegacyObject(t, dir, staged)
+
+	files := map[string]string{
+		"HEAD":            "ref: refs/heads/main\n",
+		"refs/heads/main": commit + "\n",
+		"staging.json":    fmt.Sprintf(`{"notes.txt": %q}`, stagedHash),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := NewStorage(dir, FormatCit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := s.GetBranchHead("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.GetCommit(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Message != "first" {
+		t.Fatalf("提交说明错误: %q", c.Message)
+	}
+	if _, err := s.GetTree(c.TreeHash); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.GetStaging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, ok := entries["notes.txt"]
+	if !ok {
+		t.Fatalf("迁移后暂存条目丢失: %v", entries)
+	}
+	if hash != HashObject(TypeBlob, []byte(staged)) {
+		t.Fatalf("暂存的文件没有按文件对象迁移: %s", hash)
+	}
+	data, err := s.GetObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != staged {
+		t.Fatalf("暂存的文件内容被改变: %q", data)
+	}
+}
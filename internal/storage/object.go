@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cit/internal/pack"
+)
+
+// TypeCommit 提交对象的类型名
+const TypeCommit = "commit"
+
+var objectHashPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// HashObject 计算对象的哈希：对 "<类型> <长度>\0<内容>" 取SHA-1，与Git的对象哈希一致
+func HashObject(objType string, data []byte) string {
+	hash := sha1.New()
+	hash.Write(objectHeader(objType, len(data)))
+	hash.Write(data)
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// WriteObject 写入对象并返回其哈希。对象文件的内容是经过zlib压缩的
+// "<类型> <长度>\0<内容>"，已存在的对象（包括已打包的对象）不会重复写入。
+func (s *Storage) WriteObject(objType string, data []byte) (string, error) {
+	hash := HashObject(objType, data)
+	objPath := s.objectPath(hash)
+	if _, err := os.Stat(objPath); err == nil {
+		// 刷新修改时间，避免垃圾回收把刚被重新使用的对象当作过期的不可达对象删除
+		now := time.Now()
+		os.Chtimes(objPath, now, now)
+		return hash, nil
+	}
+	if s.isPacked(hash) {
+		return hash, nil
+	}
+	if err := s.writeLooseObject(hash, objType, data); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// writeLooseObject 将对象写为松散对象文件
+func (s *Storage) writeLooseObject(hash, objType string, data []byte) error {
+	objPath := s.objectPath(hash)
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(objectHeader(objType, len(data)))
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("压缩对象失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(objPath), 0755); err != nil {
+		return fmt.Errorf("创建对象目录失败: %v", err)
+	}
+	if err := writeFileAtomic(objPath, buf.String()); err != nil {
+		return fmt.Errorf("写入对象失败: %v", err)
+	}
+	return nil
+}
+
+// ReadObject 读取对象，返回对象类型和内容。读取时会重新计算哈希进行校验。
+// 对象不存在时返回的错误满足 os.IsNotExist。
+func (s *Storage) ReadObject(hash string) (string, []byte, error) {
+	if !objectHashPattern.MatchString(hash) {
+		return "", nil, fmt.Errorf("无效的对象哈希: %q", hash)
+	}
+
+	file, err := os.Open(s.objectPath(hash))
+	if os.IsNotExist(err) {
+		return s.readPackedObject(hash, err)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	objType, data, err := decodeLooseObject(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("对象 %s 已损坏: %v", hash, err)
+	}
+	if actual := HashObject(objType, data); actual != hash {
+		return "", nil, fmt.Errorf("对象校验失败: 期望 %s，实际 %s", hash, actual)
+	}
+	return objType, data, nil
+}
+
+// ObjectType 返回对象的类型
+func (s *Storage) ObjectType(hash string) (string, error) {
+	objType, _, err := s.ReadObject(hash)
+	if err != nil {
+		return "", fmt.Errorf("读取对象 %s 失败: %v", hash, err)
+	}
+	return objType, nil
+}
+
+// FindObjects 返回以指定前缀开头的所有对象哈希，前缀至少需要两个字符
+func (s *Storage) FindObjects(prefix string) ([]string, error) {
+	if len(prefix) < 2 {
+		return nil, fmt.Errorf("哈希前缀太短: %q", prefix)
+	}
+
+	// 对应的松散对象目录不存在时，对象仍可能在包文件中
+	entries, err := os.ReadDir(filepath.Join(s.basePath, "objects", prefix[:2]))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		hash := prefix[:2] + entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(hash, prefix) && objectHashPattern.MatchString(hash) {
+			found[hash] = true
+		}
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		hashes := p.Hashes()
+		for i := sort.SearchStrings(hashes, prefix); i < len(hashes) && strings.HasPrefix(hashes[i], prefix); i++ {
+			found[hashes[i]] = true
+		}
+	}
+
+	hashes := make([]string, 0, len(found))
+	for hash := range found {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+// readPackedObject 在包文件中查找松散对象目录中没有的对象，找不到时返回 notFound
+func (s *Storage) readPackedObject(hash string, notFound error) (string, []byte, error) {
+	packs, err := s.loadPacks()
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, p := range packs {
+		if !p.Contains(hash) {
+			continue
+		}
+		objType, data, err := p.Read(hash)
+		if err != nil {
+			return "", nil, fmt.Errorf("对象 %s 已损坏: %v", hash, err)
+		}
+		if actual := HashObject(objType, data); actual != hash {
+			return "", nil, fmt.Errorf("对象校验失败: 期望 %s，实际 %s", hash, actual)
+		}
+		return objType, data, nil
+	}
+	return "", nil, notFound
+}
+
+// isPacked 判断对象是否已在包文件中
+func (s *Storage) isPacked(hash string) bool {
+	packs, err := s.loadPacks()
+	if err != nil {
+		return false
+	}
+	for _, p := range packs {
+		if p.Contains(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPacks 打开 objects/pack 下的所有包文件，只在第一次调用时读取
+func (s *Storage) loadPacks() ([]*pack.Pack, error) {
+	if s.packs != nil {
+		return s.packs, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.packDir(), "*.pack"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.packs = make([]*pack.Pack, 0, len(paths))
+	for _, path := range paths {
+		p, err := pack.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("打开包文件失败: %v", err)
+		}
+		p.ExternalBase = s.ReadObject
+		s.packs = append(s.packs, p)
+	}
+	return s.packs, nil
+}
+
+// readTypedObject 读取对象并检查类型
+func (s *Storage) readTypedObject(hash, objType string) ([]byte, error) {
+	actual, data, err := s.ReadObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if actual != objType {
+		return nil, fmt.Errorf("对象 %s 是%s对象，不是%s对象", hash, actual, objType)
+	}
+	return data, nil
+}
+
+// objectHeader 返回对象头 "<类型> <长度>\0"
+func objectHeader(objType string, size int) []byte {
+	return []byte(fmt.Sprintf("%s %d\x00", objType, size))
+}
+
+// decodeLooseObject 解压对象文件并解析对象头，校验内容长度
+func decodeLooseObject(r io.Reader) (string, []byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	header, data, ok := bytes.Cut(raw, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("缺少对象头")
+	}
+	objType, sizeText, ok := strings.Cut(string(header), " ")
+	if !ok {
+		return "", nil, fmt.Errorf("无效的对象头: %q", header)
+	}
+	switch objType {
+	case TypeBlob, TypeTree, TypeCommit, TypeTag:
+	default:
+		return "", nil, fmt.Errorf("未知的对象类型: %q", objType)
+	}
+	size, err := strconv.Atoi(sizeText)
+	if err != nil || size != len(data) {
+		return "", nil, fmt.Errorf("对象长度不匹配: %q", header)
+	}
+	return objType, data, nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ZeroID 表示引用在变更前不存在（或变更后被删除）
+const ZeroID = "0000000000000000000000000000000000000000"
+
+// ReflogEntry 表示引用日志中的一条记录
+type ReflogEntry struct {
+	Old       string
+	New       string
+	Identity  string
+	Timestamp time.Time
+	Message   string
+}
+
+// BranchRef 返回分支的完整引用名，例如 refs/heads/main
+func BranchRef(name string) string {
+	return headsDir + "/" + name
+}
+
+// AppendReflog 向引用日志追加一条记录，格式与Git一致：
+//
+//	<旧ID> <新ID> <身份> <Unix时间> <时区>\t<说明>
+func (s *Storage) AppendReflog(ref string, entry *ReflogEntry) error {
+	logPath := s.reflogPath(ref)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("创建引用日志目录失败: %v", err)
+	}
+
+	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开引用日志失败: %v", err)
+	}
+	defer file.Close()
+
+	old, new := entry.Old, entry.New
+	if old == "" {
+		old = ZeroID
+	}
+	if new == "" {
+		new = ZeroID
+	}
+	message := strings.ReplaceAll(entry.Message, "\n", " ")
+
+	_, err = fmt.Fprintf(file, "%s %s %s %s\t%s\n", old, new, entry.Identity, FormatSignatureTime(entry.Timestamp), message)
+	return err
+}
+
+// ReadReflog 读取引用日志，按从新到旧的顺序返回；没有日志时返回空列表
+func (s *Storage) ReadReflog(ref string) ([]*ReflogEntry, error) {
+	file, err := os.Open(s.reflogPath(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []*ReflogEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		header, message, _ := strings.Cut(line, "\t")
+		fields := strings.SplitN(header, " ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("无效的引用日志记录: %q", line)
+		}
+		identity, timestamp, err := ParseSignature(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &ReflogEntry{
+			Old:       fields[0],
+			New:       fields[1],
+			Identity:  identity,
+			Timestamp: timestamp,
+			Message:   message,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 文件中按时间顺序追加，返回时最新的在前
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// 私有方法
+
+func (s *Storage) reflogPath(ref string) string {
+	return filepath.Join(s.basePath, "logs", filepath.FromSlash(ref))
+}
+
+// renameReflog 随分支重命名移动引用日志
+func (s *Storage) renameReflog(oldRef, newRef string) error {
+	oldPath, newPath := s.reflogPath(oldRef), s.reflogPath(newRef)
+	if !isRegularFile(oldPath) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+	s.pruneEmptyDirs(filepath.Dir(oldPath), filepath.Join(s.basePath, "logs"))
+	return nil
+}
+
+// deleteReflog 随分支删除引用日志
+func (s *Storage) deleteReflog(ref string) error {
+	logPath := s.reflogPath(ref)
+	if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.pruneEmptyDirs(filepath.Dir(logPath), filepath.Join(s.basePath, "logs"))
+	return nil
+}
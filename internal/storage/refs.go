@@ -0,0 +1,513 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 引用相关的路径
+const (
+	headFile       = "HEAD"
+	headsDir       = "refs/heads"
+	tagsDir        = "refs/tags"
+	symbolicPrefix = "ref: "
+)
+
+// ReadHead 读取 HEAD。HEAD 指向分支时返回分支名，处于分离状态时返回提交ID。
+func (s *Storage) ReadHead() (branch, commitID string, err error) {
+	data, err := os.ReadFile(filepath.Join(s.basePath, headFile))
+	if err != nil {
+		return "", "", err
+	}
+
+	content := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(content, symbolicPrefix); ok {
+		name, ok := strings.CutPrefix(ref, headsDir+"/")
+		if !ok {
+			return "", "", fmt.Errorf("HEAD 指向了无效的引用: %s", ref)
+		}
+		return name, "", nil
+	}
+
+	if content == "" {
+		return "", "", fmt.Errorf("HEAD 文件为空")
+	}
+	return "", content, nil
+}
+
+// SetHeadBranch 让 HEAD 指向指定分支
+func (s *Storage) SetHeadBranch(name string) error {
+	if err := CheckBranchName(name); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.basePath, headFile), symbolicPrefix+headsDir+"/"+name+"\n")
+}
+
+// SetHeadDetached 让 HEAD 直接指向提交（分离状态）
+func (s *Storage) SetHeadDetached(commitID string) error {
+	return writeFileAtomic(filepath.Join(s.basePath, headFile), commitID+"\n")
+}
+
+// CreateBranch 创建新分支
+func (s *Storage) CreateBranch(branch *Branch) error {
+	if err := CheckBranchName(branch.Name); err != nil {
+		return err
+	}
+	if branch.Head == "" {
+		return fmt.Errorf("分支 '%s' 必须指向一个提交", branch.Name)
+	}
+
+	if _, err := s.GetBranchHead(branch.Name); err == nil {
+		return fmt.Errorf("分支 '%s' 已存在", branch.Name)
+	}
+
+	if err := s.checkRefPath(headsDir, branch.Name); err != nil {
+		return fmt.Errorf("无法创建分支 '%s': %v", branch.Name, err)
+	}
+	return s.writeRef(s.branchPath(branch.Name), branch.Head)
+}
+
+// ListBranches 列出所有分支，按名称排序。尚无提交的当前分支也包含在内。
+func (s *Storage) ListBranches() ([]*Branch, error) {
+	refs, err := s.listRefs(headsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]*Branch, 0, len(refs)+1)
+	for name, head := range refs {
+		branches = append(branches, &Branch{Name: name, Head: head})
+	}
+
+	if current, _, err := s.ReadHead(); err == nil && current != "" {
+		if _, ok := refs[current]; !ok {
+			branches = append(branches, &Branch{Name: current})
+		}
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].Name < branches[j].Name
+	})
+	return branches, nil
+}
+
+// GetBranchHead 获取分支头。HEAD 指向的分支还没有提交时返回空字符串。
+func (s *Storage) GetBranchHead(branchName string) (string, error) {
+	if CheckBranchName(branchName) != nil {
+		return "", fmt.Errorf("分支 '%s' 不存在", branchName)
+	}
+
+	head, err := s.readNamedRef(headsDir, branchName)
+	if err == nil {
+		return head, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("读取分支 '%s' 失败: %v", branchName, err)
+	}
+
+	if current, _, err := s.ReadHead(); err == nil && current == branchName {
+		return "", nil
+	}
+	return "", fmt.Errorf("分支 '%s' 不存在", branchName)
+}
+
+// UpdateBranchHead 更新分支头
+func (s *Storage) UpdateBranchHead(branchName, commitID string) error {
+	if _, err := s.GetBranchHead(branchName); err != nil {
+		return err
+	}
+	return s.writeRef(s.branchPath(branchName), commitID)
+}
+
+// PackedRefs 读取Git的 packed-refs 文件，返回 完整引用名 -> 对象ID，文件不存在时返回空映射。
+// 以 ^ 开头的行是上一个标签剥离后的提交，这里不需要。
+func (s *Storage) PackedRefs() (map[string]string, error) {
+	refs := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.basePath, "packed-refs"))
+	if os.IsNotExist(err) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 packed-refs 失败: %v", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		id, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("无效的 packed-refs 记录: %q", line)
+		}
+		refs[name] = id
+	}
+	return refs, nil
+}
+
+// removePackedRef 从 packed-refs 中删除引用及其剥离行，引用不在其中时不修改文件
+func (s *Storage) removePackedRef(ref string) (bool, error) {
+	packedPath := filepath.Join(s.basePath, "packed-refs")
+	data, err := os.ReadFile(packedPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取 packed-refs 失败: %v", err)
+	}
+
+	var buf strings.Builder
+	removed, skipping := false, false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "^") && skipping {
+			continue
+		}
+		skipping = false
+		if _, name, ok := strings.Cut(strings.TrimSuffix(line, "\n"), " "); ok && !strings.HasPrefix(line, "#") && name == ref {
+			removed, skipping = true, true
+			continue
+		}
+		buf.WriteString(line)
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, writeFileAtomic(packedPath, buf.String())
+}
+
+// CheckBranchName 检查分支名是否合法，规则与Git的引用名一致
+func CheckBranchName(name string) error {
+	return checkRefName("分支名", name)
+}
+
+func checkRefName(kind, name string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("无效的%s '%s': %s", kind, name, reason)
+	}
+
+	switch {
+	case name == "" || name == "HEAD" || name == "@":
+		return invalid("保留名称")
+	case strings.HasPrefix(name, "-"):
+		return invalid("不能以 '-' 开头")
+	case strings.HasSuffix(name, "/") || strings.HasSuffix(name, "."):
+		return invalid("不能以 '/' 或 '.' 结尾")
+	case strings.Contains(name, "..") || strings.Contains(name, "@{") || strings.Contains(name, "//"):
+		return invalid("不能包含 '..'、'@{' 或 '//'")
+	case strings.ContainsAny(name, " ~^:?*[\\\t\n"):
+		return invalid("包含非法字符")
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock") {
+			return invalid("路径的每一段不能以 '.' 开头或以 '.lock' 结尾")
+		}
+	}
+	return nil
+}
+
+// 私有方法
+
+func (s *Storage) branchPath(name string) string {
+	return s.refPath(headsDir, name)
+}
+
+func (s *Storage) refPath(dir, name string) string {
+	return filepath.Join(s.basePath, filepath.FromSlash(dir), filepath.FromSlash(name))
+}
+
+// checkRefPath 检查新引用是否会与已有引用形成文件和目录的冲突，例如 feature 与 feature/x
+func (s *Storage) checkRefPath(dir, name string) error {
+	if info, err := os.Stat(s.refPath(dir, name)); err == nil && info.IsDir() {
+		return fmt.Errorf("已存在以 '%s/' 开头的引用", name)
+	}
+
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		prefix := strings.Join(parts[:i], "/")
+		if isRegularFile(s.refPath(dir, prefix)) {
+			return fmt.Errorf("已存在引用 '%s'", prefix)
+		}
+	}
+	return nil
+}
+
+// readNamedRef 读取引用目录下的引用，引用文件不存在时从 packed-refs 中查找，
+// 都没有时返回 os.ErrNotExist
+func (s *Storage) readNamedRef(dir, name string) (string, error) {
+	path := s.refPath(dir, name)
+	if isRegularFile(path) {
+		return readRef(path)
+	}
+
+	packed, err := s.PackedRefs()
+	if err != nil {
+		return "", err
+	}
+	if id, ok := packed[dir+"/"+name]; ok {
+		return id, nil
+	}
+	return "", os.ErrNotExist
+}
+
+// listRefs 读取引用目录下的所有引用，返回 名称 -> 对象ID 的映射。
+// Git打包到 packed-refs 中的引用也包含在内，引用文件优先。
+func (s *Storage) listRefs(dir string) (map[string]string, error) {
+	root := filepath.Join(s.basePath, filepath.FromSlash(dir))
+
+	refs := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		id, err := readRef(path)
+		if err != nil {
+			return fmt.Errorf("读取引用 '%s' 失败: %v", filepath.ToSlash(rel), err)
+		}
+
+		refs[filepath.ToSlash(rel)] = id
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	packed, err := s.PackedRefs()
+	if err != nil {
+		return nil, err
+	}
+	for ref, id := range packed {
+		name, ok := strings.CutPrefix(ref, dir+"/")
+		if _, loose := refs[name]; ok && !loose {
+			refs[name] = id
+		}
+	}
+	return refs, nil
+}
+
+// removeRef 删除引用文件和 packed-refs 中的同名引用，并清理变空的上级目录。
+// 两处都没有时返回 os.ErrNotExist。
+func (s *Storage) removeRef(dir, name string) error {
+	removed, err := s.removePackedRef(dir + "/" + name)
+	if err != nil {
+		return err
+	}
+
+	path := s.refPath(dir, name)
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) && removed {
+			return nil
+		}
+		return err
+	}
+	s.pruneEmptyDirs(filepath.Dir(path), filepath.Join(s.basePath, filepath.FromSlash(dir)))
+	return nil
+}
+
+// pruneEmptyDirs 从 dir 开始向上删除空目录，直到 root 为止
+func (s *Storage) pruneEmptyDirs(dir, root string) {
+	for ; dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+}
+
+// readRef 读取引用文件中的提交ID
+func readRef(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	id := strings.TrimSpace(string(data))
+	if id == "" {
+		return "", fmt.Errorf("引用文件为空")
+	}
+	return id, nil
+}
+
+func (s *Storage) writeRef(path, commitID string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建引用目录失败: %v", err)
+	}
+	return writeFileAtomic(path, commitID+"\n")
+}
+
+// writeFileAtomic 先写入 .lock 临时文件再重命名，避免写到一半的引用文件
+func writeFileAtomic(path, content string) error {
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, []byte(content), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(lockPath, path); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	return nil
+}
+
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// migrateBranches 将旧版本的 branches.json 转换为 refs/heads 下的引用文件
+func (s *Storage) migrateBranches() error {
+	branchesFile := filepath.Join(s.basePath, "branches.json")
+	data, err := os.ReadFile(branchesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var branches []*Branch
+	if err := json.Unmarshal(data, &branches); err != nil {
+		return fmt.Errorf("解析 branches.json 失败: %v", err)
+	}
+
+	for _, branch := range branches {
+		// 还没有提交的分支没有引用文件
+		if branch.Head == "" {
+			continue
+		}
+		refPath := s.branchPath(branch.Name)
+		if isRegularFile(refPath) {
+			continue
+		}
+		if err := s.writeRef(refPath, branch.Head); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(branchesFile)
+}
+
+// DeleteBranch 删除分支引用及其引用日志、上游配置
+func (s *Storage) DeleteBranch(name string) error {
+	if _, err := s.readNamedRef(headsDir, name); err != nil {
+		return fmt.Errorf("分支 '%s' 不存在", name)
+	}
+	if err := s.removeRef(headsDir, name); err != nil {
+		return fmt.Errorf("删除分支 '%s' 失败: %v", name, err)
+	}
+	if err := s.deleteReflog(BranchRef(name)); err != nil {
+		return err
+	}
+	return s.SetUpstream(name, "")
+}
+
+// RenameBranch 重命名分支，同时迁移引用日志和上游配置；HEAD 指向该分支时一并更新
+func (s *Storage) RenameBranch(oldName, newName string) error {
+	head, err := s.GetBranchHead(oldName)
+	if err != nil {
+		return err
+	}
+	if err := CheckBranchName(newName); err != nil {
+		return err
+	}
+	if _, err := s.GetBranchHead(newName); err == nil {
+		return fmt.Errorf("分支 '%s' 已存在", newName)
+	}
+
+	current, _, _ := s.ReadHead()
+	if head != "" {
+		// 先删除旧引用，以便 feature 可以重命名为 feature/x
+		if err := s.removeRef(headsDir, oldName); err != nil {
+			return fmt.Errorf("删除分支 '%s' 失败: %v", oldName, err)
+		}
+		if err := s.checkRefPath(headsDir, newName); err != nil {
+			s.writeRef(s.branchPath(oldName), head)
+			return fmt.Errorf("无法重命名为 '%s': %v", newName, err)
+		}
+		if err := s.writeRef(s.branchPath(newName), head); err != nil {
+			return err
+		}
+	}
+
+	if err := s.renameReflog(BranchRef(oldName), BranchRef(newName)); err != nil {
+		return fmt.Errorf("移动引用日志失败: %v", err)
+	}
+
+	if current == oldName {
+		if err := s.SetHeadBranch(newName); err != nil {
+			return err
+		}
+	}
+
+	upstream, err := s.GetUpstream(oldName)
+	if err != nil {
+		return err
+	}
+	if upstream != "" {
+		if err := s.SetUpstream(oldName, ""); err != nil {
+			return err
+		}
+		return s.SetUpstream(newName, upstream)
+	}
+	return nil
+}
+
+// SetUpstream 设置分支跟踪的上游分支，upstream 为空时取消跟踪
+func (s *Storage) SetUpstream(branch, upstream string) error {
+	upstreams, err := s.loadUpstreams()
+	if err != nil {
+		return err
+	}
+
+	if upstream == "" {
+		if _, ok := upstreams[branch]; !ok {
+			return nil
+		}
+		delete(upstreams, branch)
+	} else {
+		upstreams[branch] = upstream
+	}
+
+	data, err := json.MarshalIndent(upstreams, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.basePath, "upstreams.json"), string(data))
+}
+
+// GetUpstream 返回分支跟踪的上游分支，没有设置时返回空字符串
+func (s *Storage) GetUpstream(branch string) (string, error) {
+	upstreams, err := s.loadUpstreams()
+	if err != nil {
+		return "", err
+	}
+	return upstreams[branch], nil
+}
+
+func (s *Storage) loadUpstreams() (map[string]string, error) {
+	upstreams := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.basePath, "upstreams.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return upstreams, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &upstreams); err != nil {
+		return nil, fmt.Errorf("解析上游配置失败: %v", err)
+	}
+	return upstreams, nil
+}
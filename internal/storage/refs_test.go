@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackedRefsFallback(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStorage(dir, FormatGit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		mainID = "1111111111111111111111111111111111111111"
+		sideID = "2222222222222222222222222222222222222222"
+		tagID  = "3333333333333333333333333333333333333333"
+		peeled = "4444444444444444444444444444444444444444"
+	)
+	packed := "# pack-refs with: peeled fully-peeled sorted \n" +
+		mainID + " refs/heads/main\n" +
+		sideID + " refs/heads/side\n" +
+		tagID + " refs/tags/v1\n" +
+		"^" + peeled + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "packed-refs"), []byte(packed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetHeadBranch("main"); err != nil {
+		t.Fatal(err)
+	}
+
+	if head, err := s.GetBranchHead("main"); err != nil || head != mainID {
+		t.Fatalf("应从 packed-refs 读取分支: %q, %v", head, err)
+	}
+	if target, err := s.GetTagRef("v1"); err != nil || target != tagID {
+		t.Fatalf("应从 packed-refs 读取标签: %q, %v", target, err)
+	}
+	branches, err := s.ListBranches()
+	if err != nil || len(branches) != 2 {
+		t.Fatalf("分支列表应包含打包的分支: %v, %v", branches, err)
+	}
+
+	// 引用文件优先于 packed-refs
+	if err := s.UpdateBranchHead("main", sideID); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := s.GetBranchHead("main"); head != sideID {
+		t.Fatalf("引用文件应优先于 packed-refs: %q", head)
+	}
+
+	// 删除只在 packed-refs 中的引用时，一并删除剥离行
+	if err := s.DeleteTagRef("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteBranch("side"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "packed-refs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "v1") || strings.Contains(string(data), peeled) || strings.Contains(string(data), "side") {
+		t.Fatalf("packed-refs 中仍有已删除的引用:\n%s", data)
+	}
+	if _, err := s.GetTagRef("v1"); err == nil {
+		t.Fatalf("删除后标签仍然存在")
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stagingVersion 暂存区文件格式版本。版本1是 路径 -> 哈希 的扁平映射
+const stagingVersion = 2
+
+// ConflictEntry 记录合并冲突文件在三方中的版本（对应Git索引的1、2、3阶段），
+// 哈希为空表示该文件在这一方不存在
+type ConflictEntry struct {
+	Base   string `json:"base"`
+	Ours   string `json:"ours"`
+	Theirs string `json:"theirs"`
+}
+
+// Kind 返回冲突类型的描述
+func (c *ConflictEntry) Kind() string {
+	switch {
+	case c.Ours == "":
+		return "我们删除"
+	case c.Theirs == "":
+		return "对方删除"
+	case c.Base == "":
+		return "双方添加"
+	default:
+		return "双方修改"
+	}
+}
+
+// stagingIndex 是暂存区文件的内容
+type stagingIndex struct {
+	Version   int                       `json:"version"`
+	Entries   map[string]string         `json:"entries"`
+	Conflicts map[string]*ConflictEntry `json:"conflicts,omitempty"`
+}
+
+// AddToStaging 添加文件到暂存区，哈希为空表示暂存删除。
+// 重新暂存一个冲突文件即表示冲突已解决。
+func (s *Storage) AddToStaging(filePath, hash string) error {
+	index, err := s.loadStaging()
+	if err != nil {
+		return err
+	}
+
+	index.Entries[filePath] = hash
+	delete(index.Conflicts, filePath)
+
+	return s.saveStaging(index)
+}
+
+// RemoveFromStaging 移除文件的暂存条目和冲突记录，使其恢复为HEAD中的版本
+func (s *Storage) RemoveFromStaging(filePath string) error {
+	index, err := s.loadStaging()
+	if err != nil {
+		return err
+	}
+
+	delete(index.Entries, filePath)
+	delete(index.Conflicts, filePath)
+
+	return s.saveStaging(index)
+}
+
+// SetStaging 用给定的条目替换整个暂存区，同时清除所有冲突记录
+func (s *Storage) SetStaging(entries map[string]string) error {
+	return s.saveStaging(&stagingIndex{Entries: entries})
+}
+
+// GetStaging 获取暂存区内容
+func (s *Storage) GetStaging() (map[string]string, error) {
+	index, err := s.loadStaging()
+	if err != nil {
+		return nil, err
+	}
+	return index.Entries, nil
+}
+
+// SetConflict 在暂存区中记录冲突文件，同时移除该文件的普通暂存条目
+func (s *Storage) SetConflict(filePath string, conflict *ConflictEntry) error {
+	index, err := s.loadStaging()
+	if err != nil {
+		return err
+	}
+
+	delete(index.Entries, filePath)
+	index.Conflicts[filePath] = conflict
+
+	return s.saveStaging(index)
+}
+
+// GetConflicts 获取暂存区中未解决的冲突
+func (s *Storage) GetConflicts() (map[string]*ConflictEntry, error) {
+	index, err := s.loadStaging()
+	if err != nil {
+		return nil, err
+	}
+	return index.Conflicts, nil
+}
+
+// ClearStaging 清空暂存区
+func (s *Storage) ClearStaging() error {
+	stagingFile := filepath.Join(s.basePath, "staging.json")
+	if err := os.Remove(stagingFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// 私有方法
+
+func (s *Storage) loadStaging() (*stagingIndex, error) {
+	stagingFile := filepath.Join(s.basePath, "staging.json")
+
+	index := &stagingIndex{Version: stagingVersion}
+	if data, err := os.ReadFile(stagingFile); err == nil {
+		// 旧版本的扁平映射没有 version 字段，解析后版本号为0
+		index = &stagingIndex{}
+		if err := json.Unmarshal(data, index); err != nil || index.Version < stagingVersion {
+			// 兼容旧版本的扁平映射格式
+			index = &stagingIndex{Version: stagingVersion}
+			if err := json.Unmarshal(data, &index.Entries); err != nil {
+				return nil, fmt.Errorf("解析暂存区失败: %v", err)
+			}
+		}
+	}
+
+	if index.Entries == nil {
+		index.Entries = make(map[string]string)
+	}
+	if index.Conflicts == nil {
+		index.Conflicts = make(map[string]*ConflictEntry)
+	}
+	return index, nil
+}
+
+func (s *Storage) saveStaging(index *stagingIndex) error {
+	stagingFile := filepath.Join(s.basePath, "staging.json")
+	index.Version = stagingVersion
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stagingFile, data, 0644)
+}
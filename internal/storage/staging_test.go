@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLegacyStaging(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStorage(dir, FormatCit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := `{"p.txt": "0123456789012345678901234567890123456789"}`
+	if err := os.WriteFile(filepath.Join(dir, "staging.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.GetStaging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries["p.txt"] != "0123456789012345678901234567890123456789" || len(entries) != 1 {
+		t.Fatalf("旧格式的暂存条目没有被读出: %v", entries)
+	}
+
+	// 改写后保存为新格式，条目保持不变
+	if err := s.AddToStaging("q.txt", "abcdefabcdefabcdefabcdefabcdefabcdefabcd"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = s.GetStaging()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries["p.txt"] == "" {
+		t.Fatalf("保存后丢失了暂存条目: %v", entries)
+	}
+}
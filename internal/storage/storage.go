@@ -1,24 +1,25 @@
 package storage
 
 import (
-	"crypto/sha1"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"time"
+
+	"cit/internal/pack"
 )
 
-// Commit 表示一个提交
+// Commit 表示一个提交，ID 为提交对象的哈希（内容见 Encode）
 type Commit struct {
-	ID        string    `json:"id"`
-	Message   string    `json:"message"`
-	Author    string    `json:"author"`
-	Timestamp time.Time `json:"timestamp"`
-	ParentID  string    `json:"parent_id"`
-	TreeHash  string    `json:"tree_hash"`
+	ID              string    `json:"id"`
+	Message         string    `json:"message"`
+	Author          string    `json:"author"`
+	Timestamp       time.Time `json:"timestamp"`
+	Committer       string    `json:"committer,omitempty"`
+	CommitTimestamp time.Time `json:"commit_timestamp,omitempty"`
+	Parents         []string  `json:"parents"`
+	TreeHash        string    `json:"tree_hash"`
 }
 
 // Branch 表示一个分支
@@ -36,12 +37,18 @@ type Remote struct {
 // Storage 管理Git仓库的数据存储
 type Storage struct {
 	basePath string
+	format   string
+	packs    []*pack.Pack // 延迟加载，见 loadPacks
 }
 
-// NewStorage 创建新的存储实例
-func NewStorage(basePath string) (*Storage, error) {
+// NewStorage 创建新的存储实例，format 为 FormatCit 或 FormatGit
+func NewStorage(basePath, format string) (*Storage, error) {
+	if format != FormatCit && format != FormatGit {
+		return nil, fmt.Errorf("未知的仓库格式: %q", format)
+	}
 	storage := &Storage{
 		basePath: basePath,
+		format:   format,
 	}
 
 	// 确保必要的目录存在
@@ -57,245 +64,102 @@ func NewStorage(basePath string) (*Storage, error) {
 		}
 	}
 
-	return storage, nil
-}
-
-// StoreObject 存储文件对象
-func (s *Storage) StoreObject(hash, filePath string) error {
-	// 创建对象目录
-	objDir := filepath.Join(s.basePath, "objects", hash[:2])
-	if err := os.MkdirAll(objDir, 0755); err != nil {
-		return fmt.Errorf("创建对象目录失败: %v", err)
-	}
-
-	// 复制文件到对象存储
-	objPath := filepath.Join(objDir, hash[2:])
-	srcFile, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("打开源文件失败: %v", err)
-	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(objPath)
-	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %v", err)
+	if format == FormatGit {
+		if err := storage.writeGitConfig(); err != nil {
+			return nil, fmt.Errorf("写入配置失败: %v", err)
+		}
+		return storage, nil
 	}
-	defer dstFile.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("复制文件失败: %v", err)
+	// 旧版本把所有分支保存在 branches.json 中，迁移为独立的引用文件
+	if err := storage.migrateBranches(); err != nil {
+		return nil, fmt.Errorf("迁移分支信息失败: %v", err)
 	}
 
-	return nil
-}
-
-// AddToStaging 添加文件到暂存区
-func (s *Storage) AddToStaging(filePath, hash string) error {
-	stagingFile := filepath.Join(s.basePath, "staging.json")
-	
-	// 读取现有暂存区
-	staging := make(map[string]string)
-	if data, err := os.ReadFile(stagingFile); err == nil {
-		if err := json.Unmarshal(data, &staging); err != nil {
-			return fmt.Errorf("解析暂存区失败: %v", err)
-		}
+	// 旧版本的对象不带类型且未压缩，迁移为新的对象格式
+	if err := storage.migrateObjects(); err != nil {
+		return nil, fmt.Errorf("迁移对象失败: %v", err)
 	}
 
-	// 添加新文件
-	staging[filePath] = hash
-
-	// 保存暂存区
-	return s.saveStaging(staging)
+	return storage, nil
 }
 
-// GetStaging 获取暂存区内容
-func (s *Storage) GetStaging() (map[string]string, error) {
-	stagingFile := filepath.Join(s.basePath, "staging.json")
-	
-	staging := make(map[string]string)
-	if data, err := os.ReadFile(stagingFile); err == nil {
-		if err := json.Unmarshal(data, &staging); err != nil {
-			return nil, fmt.Errorf("解析暂存区失败: %v", err)
-		}
+// OpenGitDir 以只读方式打开Git仓库的元数据目录（例如另一个项目的 .git），
+// 不创建任何目录或文件，用于读取其中的对象和引用
+func OpenGitDir(gitDir string) (*Storage, error) {
+	info, err := os.Stat(filepath.Join(gitDir, "objects"))
+	if err != nil || !info.IsDir() || !isRegularFile(filepath.Join(gitDir, headFile)) {
+		return nil, fmt.Errorf("%s 不是Git仓库目录", gitDir)
 	}
-
-	return staging, nil
-}
-
-// ClearStaging 清空暂存区
-func (s *Storage) ClearStaging() error {
-	stagingFile := filepath.Join(s.basePath, "staging.json")
-	return os.Remove(stagingFile)
+	return &Storage{basePath: gitDir, format: FormatGit}, nil
 }
 
-// StoreCommit 存储提交对象
-func (s *Storage) StoreCommit(commit *Commit) error {
-	// 将提交序列化为JSON
-	data, err := json.MarshalIndent(commit, "", "  ")
+// StoreObject 将文件内容存储为文件对象，返回对象哈希
+func (s *Storage) StoreObject(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("序列化提交失败: %v", err)
+		return "", fmt.Errorf("读取源文件失败: %v", err)
 	}
-
-	// 计算提交哈希
-	hash := fmt.Sprintf("%x", sha1.Sum(data))
-
-	// 创建对象目录
-	objDir := filepath.Join(s.basePath, "objects", hash[:2])
-	if err := os.MkdirAll(objDir, 0755); err != nil {
-		return fmt.Errorf("创建对象目录失败: %v", err)
-	}
-
-	// 保存提交对象
-	objPath := filepath.Join(objDir, hash[2:])
-	if err := os.WriteFile(objPath, data, 0644); err != nil {
-		return fmt.Errorf("保存提交对象失败: %v", err)
-	}
-
-	// 更新提交ID
-	commit.ID = hash
-
-	// 保存到提交索引
-	return s.saveCommitIndex(commit)
+	return s.StoreBlob(data)
 }
 
-// GetCommitHistory 获取提交历史
-func (s *Storage) GetCommitHistory() ([]*Commit, error) {
-	indexFile := filepath.Join(s.basePath, "commits.json")
-	
-	var commits []*Commit
-	if data, err := os.ReadFile(indexFile); err == nil {
-		if err := json.Unmarshal(data, &commits); err != nil {
-			return nil, fmt.Errorf("解析提交索引失败: %v", err)
-		}
+// StoreBlob 存储内存中的文件内容，返回对象哈希
+func (s *Storage) StoreBlob(data []byte) (string, error) {
+	hash, err := s.WriteObject(TypeBlob, data)
+	if err != nil {
+		return "", fmt.Errorf("存储文件对象失败: %v", err)
 	}
-
-	// 按时间排序（最新的在前）
-	sort.Slice(commits, func(i, j int) bool {
-		return commits[i].Timestamp.After(commits[j].Timestamp)
-	})
-
-	return commits, nil
+	return hash, nil
 }
 
-// CreateBranch 创建新分支
-func (s *Storage) CreateBranch(branch *Branch) error {
-	branches, err := s.ListBranches()
+// GetObject 读取对象内容
+func (s *Storage) GetObject(hash string) ([]byte, error) {
+	_, data, err := s.ReadObject(hash)
 	if err != nil {
-		return err
-	}
-
-	// 检查分支是否已存在
-	for _, existingBranch := range branches {
-		if existingBranch.Name == branch.Name {
-			return fmt.Errorf("分支 '%s' 已存在", branch.Name)
-		}
+		return nil, fmt.Errorf("读取对象 %s 失败: %v", hash, err)
 	}
-
-	// 添加新分支
-	branches = append(branches, branch)
-
-	// 保存分支列表
-	return s.saveBranches(branches)
+	return data, nil
 }
 
-// ListBranches 列出所有分支
-func (s *Storage) ListBranches() ([]*Branch, error) {
-	branchesFile := filepath.Join(s.basePath, "branches.json")
-	
-	var branches []*Branch
-	if data, err := os.ReadFile(branchesFile); err == nil {
-		if err := json.Unmarshal(data, &branches); err != nil {
-			return nil, fmt.Errorf("解析分支列表失败: %v", err)
-		}
+// StoreCommit 存储提交对象，并将提交ID设置为其对象哈希
+func (s *Storage) StoreCommit(commit *Commit) error {
+	hash, err := s.WriteObject(TypeCommit, commit.Encode())
+	if err != nil {
+		return fmt.Errorf("保存提交对象失败: %v", err)
 	}
 
-	return branches, nil
+	// 更新提交ID
+	commit.ID = hash
+	return nil
 }
 
-// GetBranchHead 获取分支头
-func (s *Storage) GetBranchHead(branchName string) (string, error) {
-	branches, err := s.ListBranches()
+// GetCommit 根据ID读取提交对象
+func (s *Storage) GetCommit(id string) (*Commit, error) {
+	data, err := s.readTypedObject(id, TypeCommit)
 	if err != nil {
-		return "", err
-	}
-
-	for _, branch := range branches {
-		if branch.Name == branchName {
-			return branch.Head, nil
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("提交 '%s' 不存在", id)
 		}
+		return nil, fmt.Errorf("读取提交 '%s' 失败: %v", id, err)
 	}
 
-	return "", fmt.Errorf("分支 '%s' 不存在", branchName)
-}
-
-// UpdateBranchHead 更新分支头
-func (s *Storage) UpdateBranchHead(branchName, commitID string) error {
-	branches, err := s.ListBranches()
+	commit, err := decodeCommit(id, data)
 	if err != nil {
-		return err
-	}
-
-	// 查找并更新分支
-	for _, branch := range branches {
-		if branch.Name == branchName {
-			branch.Head = commitID
-			return s.saveBranches(branches)
-		}
+		return nil, fmt.Errorf("解析提交 '%s' 失败: %v", id, err)
 	}
 
-	return fmt.Errorf("分支 '%s' 不存在", branchName)
+	return commit, nil
 }
 
 // 私有方法
 
-func (s *Storage) saveStaging(staging map[string]string) error {
-	stagingFile := filepath.Join(s.basePath, "staging.json")
-	data, err := json.MarshalIndent(staging, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(stagingFile, data, 0644)
+func (s *Storage) objectPath(hash string) string {
+	return filepath.Join(s.basePath, "objects", hash[:2], hash[2:])
 }
 
-func (s *Storage) saveCommitIndex(commit *Commit) error {
-	indexFile := filepath.Join(s.basePath, "commits.json")
-	
-	var commits []*Commit
-	if data, err := os.ReadFile(indexFile); err == nil {
-		if err := json.Unmarshal(data, &commits); err != nil {
-			return fmt.Errorf("解析提交索引失败: %v", err)
-		}
-	}
-
-	// 检查是否已存在
-	for i, existingCommit := range commits {
-		if existingCommit.ID == commit.ID {
-			commits[i] = commit
-			return s.saveCommits(commits)
-		}
-	}
-
-	// 添加新提交
-	commits = append(commits, commit)
-	return s.saveCommits(commits)
-}
-
-func (s *Storage) saveCommits(commits []*Commit) error {
-	indexFile := filepath.Join(s.basePath, "commits.json")
-	data, err := json.MarshalIndent(commits, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(indexFile, data, 0644)
-}
-
-func (s *Storage) saveBranches(branches []*Branch) error {
-	branchesFile := filepath.Join(s.basePath, "branches.json")
-	data, err := json.MarshalIndent(branches, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(branchesFile, data, 0644)
+// packDir 返回包文件所在的目录
+func (s *Storage) packDir() string {
+	return filepath.Join(s.basePath, "objects", "pack")
 }
 
 // AddRemote 添加远程仓库
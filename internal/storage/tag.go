@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TypeTag 附注标签对象的类型名
+const TypeTag = "tag"
+
+// Tag 表示附注标签对象，记录被标记的对象、标记者和说明
+type Tag struct {
+	ID         string
+	Object     string // 被标记对象的哈希
+	ObjectType string // 被标记对象的类型
+	Name       string
+	Tagger     string
+	Timestamp  time.Time
+	Message    string
+}
+
+// TagRef 表示 refs/tags 下的一个标签引用
+type TagRef struct {
+	Name   string
+	Target string // 轻量标签指向提交，附注标签指向标签对象
+}
+
+// Encode 返回标签对象的规范序列化形式，与Git的标签对象格式一致：
+//
+//	object <对象哈希>
+//	type <对象类型>
+//	tag <标签名>
+//	tagger <标记者> <Unix时间> <时区>
+//
+//	<说明>
+func (t *Tag) Encode() []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "object %s\n", t.Object)
+	fmt.Fprintf(&buf, "type %s\n", t.ObjectType)
+	fmt.Fprintf(&buf, "tag %s\n", t.Name)
+	fmt.Fprintf(&buf, "tagger %s %s\n", t.Tagger, FormatSignatureTime(t.Timestamp))
+
+	buf.WriteString("\n")
+	buf.WriteString(t.Message)
+	if !strings.HasSuffix(t.Message, "\n") {
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// StoreTag 存储标签对象，并将标签ID设置为其对象哈希
+func (s *Storage) StoreTag(tag *Tag) error {
+	hash, err := s.WriteObject(TypeTag, tag.Encode())
+	if err != nil {
+		return fmt.Errorf("保存标签对象失败: %v", err)
+	}
+
+	tag.ID = hash
+	return nil
+}
+
+// GetTag 读取标签对象
+func (s *Storage) GetTag(id string) (*Tag, error) {
+	data, err := s.readTypedObject(id, TypeTag)
+	if err != nil {
+		return nil, fmt.Errorf("读取标签对象 '%s' 失败: %v", id, err)
+	}
+
+	tag, err := decodeTag(id, data)
+	if err != nil {
+		return nil, fmt.Errorf("解析标签对象 '%s' 失败: %v", id, err)
+	}
+	return tag, nil
+}
+
+// CreateTagRef 创建指向目标对象的标签引用
+func (s *Storage) CreateTagRef(name, target string) error {
+	if err := CheckTagName(name); err != nil {
+		return err
+	}
+	if _, err := s.GetTagRef(name); err == nil {
+		return fmt.Errorf("标签 '%s' 已存在", name)
+	}
+	if err := s.checkRefPath(tagsDir, name); err != nil {
+		return fmt.Errorf("无法创建标签 '%s': %v", name, err)
+	}
+	return s.writeRef(s.refPath(tagsDir, name), target)
+}
+
+// GetTagRef 读取标签引用指向的对象ID
+func (s *Storage) GetTagRef(name string) (string, error) {
+	if CheckTagName(name) != nil {
+		return "", fmt.Errorf("标签 '%s' 不存在", name)
+	}
+
+	id, err := s.readNamedRef(tagsDir, name)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("标签 '%s' 不存在", name)
+	}
+	return id, err
+}
+
+// ListTags 列出所有标签引用，按名称排序
+func (s *Storage) ListTags() ([]*TagRef, error) {
+	refs, err := s.listRefs(tagsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]*TagRef, 0, len(refs))
+	for name, target := range refs {
+		tags = append(tags, &TagRef{Name: name, Target: target})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// DeleteTagRef 删除标签引用，标签对象本身保留在对象库中
+func (s *Storage) DeleteTagRef(name string) error {
+	if _, err := s.GetTagRef(name); err != nil {
+		return err
+	}
+	if err := s.removeRef(tagsDir, name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除标签 '%s' 失败: %v", name, err)
+	}
+	return nil
+}
+
+// CheckTagName 检查标签名是否合法
+func CheckTagName(name string) error {
+	return checkRefName("标签名", name)
+}
+
+// decodeTag 解析标签对象
+func decodeTag(id string, data []byte) (*Tag, error) {
+	header, message, ok := strings.Cut(string(data), "\n\n")
+	if !ok {
+		return nil, fmt.Errorf("缺少标签说明")
+	}
+
+	tag := &Tag{
+		ID:      id,
+		Message: strings.TrimSuffix(message, "\n"),
+	}
+
+	for _, line := range strings.Split(header, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		var err error
+		switch key {
+		case "object":
+			tag.Object = value
+		case "type":
+			tag.ObjectType = value
+		case "tag":
+			tag.Name = value
+		case "tagger":
+			tag.Tagger, tag.Timestamp, err = ParseSignature(value)
+		default:
+			err = fmt.Errorf("未知的标签字段 %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tag.Object == "" || tag.ObjectType == "" || tag.Name == "" {
+		return nil, fmt.Errorf("标签对象缺少必要字段")
+	}
+	return tag, nil
+}
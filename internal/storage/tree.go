@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 文件模式，与Git保持一致
+const (
+	ModeFile       = "100644"
+	ModeExecutable = "100755"
+	ModeTree       = "040000"
+	ModeSymlink    = "120000"
+	ModeSubmodule  = "160000" // 子模块，条目指向另一个仓库中的提交
+)
+
+// 对象类型
+const (
+	TypeBlob = "blob"
+	TypeTree = "tree"
+)
+
+// TreeEntry 表示树对象中的一个条目
+type TreeEntry struct {
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	Hash string `json:"hash"`
+	Name string `json:"name"`
+}
+
+// Tree 表示一个目录快照
+type Tree struct {
+	Entries []*TreeEntry `json:"entries"`
+}
+
+// IsTree 判断条目是否为子目录
+func (e *TreeEntry) IsTree() bool {
+	return e.Type == TypeTree
+}
+
+// CheckEntryName 检查树条目名称，规则与 git fsck 一致：不能为空、"." 或 ".."，
+// 不能包含 "/"，也不能是 .git（不区分大小写）。这样的名称在检出时会写到工作目录之外或元数据目录中。
+func CheckEntryName(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return fmt.Errorf("无效的树条目名称: %q", name)
+	case strings.ContainsAny(name, "/\x00"):
+		return fmt.Errorf("树条目名称不能包含 '/' 或空字符: %q", name)
+	case strings.EqualFold(name, ".git"):
+		return fmt.Errorf("树条目名称不能是 %q", name)
+	}
+	return nil
+}
+
+// StoreTree 存储树对象，返回树的哈希
+func (s *Storage) StoreTree(tree *Tree) (string, error) {
+	data := encodeTree(tree)
+	if s.format == FormatGit {
+		var err error
+		if data, err = encodeGitTree(tree); err != nil {
+			return "", fmt.Errorf("保存树对象失败: %v", err)
+		}
+	}
+
+	hash, err := s.WriteObject(TypeTree, data)
+	if err != nil {
+		return "", fmt.Errorf("保存树对象失败: %v", err)
+	}
+
+	return hash, nil
+}
+
+// GetTree 读取树对象
+func (s *Storage) GetTree(hash string) (*Tree, error) {
+	data, err := s.readTypedObject(hash, TypeTree)
+	if err != nil {
+		return nil, fmt.Errorf("读取树对象 %s 失败: %v", hash, err)
+	}
+
+	decode := decodeTree
+	if s.format == FormatGit {
+		decode = decodeGitTree
+	}
+	tree, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析树对象 %s 失败: %v", hash, err)
+	}
+
+	return tree, nil
+}
+
+// encodeTree 将树序列化为文本格式，每行一个条目：
+// <mode> <type> <hash>\t<name>
+func encodeTree(tree *Tree) []byte {
+	entries := make([]*TreeEntry, len(tree.Entries))
+	copy(entries, tree.Entries)
+	sortTreeEntries(entries)
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "%s %s %s\t%s\n", entry.Mode, entry.Type, entry.Hash, entry.Name)
+	}
+	return buf.Bytes()
+}
+
+// decodeTree 解析文本格式的树对象
+func decodeTree(data []byte) (*Tree, error) {
+	tree := &Tree{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+
+		header, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("无效的树条目: %q", line)
+		}
+
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("无效的树条目: %q", line)
+		}
+
+		if err := CheckEntryName(name); err != nil {
+			return nil, err
+		}
+
+		tree.Entries = append(tree.Entries, &TreeEntry{
+			Mode: fields[0],
+			Type: fields[1],
+			Hash: fields[2],
+			Name: name,
+		})
+	}
+	return tree, nil
+}
+
+// sortTreeEntries 按Git的规则排序：目录名按追加"/"后参与比较
+func sortTreeEntries(entries []*TreeEntry) {
+	sortKey := func(e *TreeEntry) string {
+		if e.IsTree() {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return sortKey(entries[i]) < sortKey(entries[j])
+	})
+}
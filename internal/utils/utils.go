@@ -8,7 +8,8 @@ import (
 	"time"
 )
 
-// CalculateFileHash 计算文件的SHA1哈希
+// CalculateFileHash 计算文件作为文件对象的SHA1哈希，即对 "blob <长度>\0<内容>" 取哈希，
+// 与对象库中的哈希一致
 func CalculateFileHash(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -16,7 +17,13 @@ func CalculateFileHash(filePath string) (string, error) {
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("读取文件信息失败: %v", err)
+	}
+
 	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", info.Size())
 	if _, err := io.Copy(hash, file); err != nil {
 		return "", fmt.Errorf("读取文件失败: %v", err)
 	}